@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+const defaultBundleName = "pokedex-bundle.zip"
+
+// bundleKinds are the endpoints the commands look things up in, which a
+// bundle includes unless told otherwise.
+var bundleKinds = []pokeapi.ResourceKind{
+	pokeapi.KindPokemon,
+	pokeapi.KindLocationArea,
+	pokeapi.KindMove,
+	pokeapi.KindItem,
+	pokeapi.KindType,
+	pokeapi.KindGeneration,
+	pokeapi.KindPokedex,
+}
+
+// runBundle handles "pokedexcli bundle build", which crawls the API into a
+// bundle for --offline.
+func runBundle(args []string) error {
+	if len(args) == 0 || args[0] != "build" {
+		return fmt.Errorf("usage: pokedexcli bundle build [-o file] [-kinds list] [-workers n]")
+	}
+
+	flags := flag.NewFlagSet("pokedexcli bundle build", flag.ContinueOnError)
+	output := flags.String("o", defaultBundleName, "write the bundle to `file`")
+	defaultKinds := make([]string, len(bundleKinds))
+	for i, kind := range bundleKinds {
+		defaultKinds[i] = string(kind)
+	}
+	kindList := flags.String("kinds", strings.Join(defaultKinds, ","), "comma-separated `list` of endpoints to include")
+	workers := flags.Int("workers", pokeapi.DefaultWorkers, "number of concurrent requests")
+	if err := flags.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *workers <= 0 {
+		return fmt.Errorf("invalid number of workers %d", *workers)
+	}
+	var kinds []pokeapi.ResourceKind
+	for _, kind := range strings.Split(*kindList, ",") {
+		if kind = pokeapi.NormalizeName(kind); kind != "" {
+			kinds = append(kinds, pokeapi.ResourceKind(kind))
+		}
+	}
+	if len(kinds) == 0 {
+		return fmt.Errorf("no endpoints to include")
+	}
+
+	// Responses are kept in the disk cache as they come in, so building
+	// again after an interruption or failures only fetches what's missing.
+	apiOptions := []pokeapi.Option{
+		pokeapi.WithMemoryBudget(memoryCacheBytes, 0),
+		pokeapi.WithCompression(compressAbove),
+	}
+	if dir, err := cacheDir(); err == nil {
+		apiOptions = append(apiOptions, pokeapi.WithDiskCache(dir))
+	}
+	api := pokeapi.NewPokeAPIWrapper(cacheTTL, apiOptions...)
+	defer api.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Write next to the output and rename at the end so a failed build
+	// doesn't clobber an existing bundle.
+	tmp, err := os.CreateTemp(filepath.Dir(*output), ".bundle-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	failed, err := pokeapi.BuildBundle(ctx, api, tmp, kinds, pokeapi.CrawlOptions{
+		Workers:  *workers,
+		Progress: progressPrinter(os.Stdout),
+	})
+	fmt.Println()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), *output); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		fmt.Printf("%d resources couldn't be fetched and are missing from the bundle:\n", len(failed))
+		for i, crawlErr := range failed {
+			if i == maxListedCrawlErrors {
+				fmt.Printf("  ... and %d more\n", len(failed)-i)
+				break
+			}
+			fmt.Printf("  - %s: %v\n", crawlErr.Resource.Name, crawlErr.Err)
+		}
+	}
+	fmt.Printf("Wrote %s. Run pokedexcli --offline %s to use it.\n", *output, *output)
+	return nil
+}
+
+// missingBundleKinds returns the endpoints the commands use that b lacks.
+func missingBundleKinds(b *pokeapi.Bundle) []string {
+	var missing []string
+	for _, kind := range bundleKinds {
+		if !slices.Contains(b.Kinds(), kind) {
+			missing = append(missing, string(kind))
+		}
+	}
+	return missing
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+var cacheSubcommands = []string{"stats", "ls", "show", "evict", "clear", "warm"}
+
+func completeCacheSubcommands(s *session) []string {
+	return cacheSubcommands
+}
+
+func commandCache(s *session, in *invocation) error {
+	arg := in.str("target")
+	switch in.str("subcommand") {
+	case "stats":
+		return cacheStats(s, in)
+	case "ls":
+		return cacheList(s, in, cacheURL(s, arg))
+	case "show":
+		if arg == "" {
+			return fmt.Errorf("usage: cache show <url>")
+		}
+		return cacheShow(s, in, cacheURL(s, arg))
+	case "evict":
+		if arg == "" {
+			return fmt.Errorf("usage: cache evict <url|prefix>")
+		}
+		n, err := s.api.Evict(cacheURL(s, arg))
+		fmt.Fprintf(in.out, "Evicted %d cached responses.\n", n)
+		return err
+	case "clear":
+		if err := s.api.ClearCache(); err != nil {
+			return err
+		}
+		fmt.Fprintln(in.out, "Cleared the cache.")
+		return nil
+	case "warm":
+		if arg == "" {
+			return fmt.Errorf("usage: cache warm <endpoint>, e.g. cache warm pokemon")
+		}
+		kind := pokeapi.ResourceKind(pokeapi.NormalizeName(arg))
+		_, _, err := crawl[resourceSummary](s, in, kind, pokeapi.CrawlOptions{
+			Progress: progressPrinter(in.out),
+		}, "cache warm "+string(kind))
+		return err
+	}
+	return fmt.Errorf("unknown subcommand %q, expected one of %s",
+		in.str("subcommand"), strings.Join(cacheSubcommands, ", "))
+}
+
+// cacheURL turns a cache command argument into a URL or URL prefix; a
+// path such as "pokemon/25" is taken relative to the API's base URL.
+func cacheURL(s *session, arg string) string {
+	if arg == "" || strings.Contains(arg, "://") {
+		return arg
+	}
+	return s.api.BaseURL + "/" + strings.TrimPrefix(arg, "/")
+}
+
+func cacheStats(s *session, in *invocation) error {
+	stats, err := s.api.CacheStats()
+	if err != nil {
+		return err
+	}
+	size := formatBytes(int64(stats.MemoryBytes))
+	if stats.MemoryUncompressedBytes != stats.MemoryBytes {
+		size += fmt.Sprintf(" (%s uncompressed)", formatBytes(int64(stats.MemoryUncompressedBytes)))
+	}
+	fmt.Fprintf(in.out, "Memory:   %d entries, %s, %d evicted, %d expired\n",
+		stats.MemoryEntries, size, stats.MemoryEvictions, stats.MemoryExpired)
+	fmt.Fprintf(in.out, "Decoded:  %d objects, about %s\n",
+		stats.MemoryObjects, formatBytes(int64(stats.MemoryObjectBytes)))
+	if stats.DiskEnabled {
+		fmt.Fprintf(in.out, "Disk:     %d entries, %s\n", stats.DiskEntries, formatBytes(stats.DiskBytes))
+	} else {
+		fmt.Fprintln(in.out, "Disk:     not in use")
+	}
+	fmt.Fprintf(in.out, "Requests: %d hits, %d misses, %d coalesced, %d revalidated\n",
+		stats.Hits, stats.Misses, stats.Coalesced, stats.Revalidated)
+	return nil
+}
+
+func cacheList(s *session, in *invocation, prefix string) error {
+	entries, err := s.api.CacheEntries(prefix)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		tiers := cacheTiers(entry)
+		expires := "-"
+		if !entry.Expires.IsZero() {
+			expires = formatExpiry(entry.Expires, now)
+		}
+		fmt.Fprintf(in.out, "%-9s %10s  %-12s %s\n", tiers, formatBytes(entry.Size), expires, entry.URL)
+		in.emit(newRecord(entry.URL).
+			set("size", strconv.FormatInt(entry.Size, 10)).
+			set("tier", strings.Split(tiers, ",")...))
+	}
+	fmt.Fprintf(in.out, "%d cached responses\n", len(entries))
+	return nil
+}
+
+func cacheShow(s *session, in *invocation, url string) error {
+	entry, body, ok := s.api.CachedResponse(url)
+	if !ok {
+		return fmt.Errorf("%s is not cached", url)
+	}
+	fmt.Fprintf(in.out, "URL:           %s\n", entry.URL)
+	fmt.Fprintf(in.out, "Cached in:     %s\n", cacheTiers(entry))
+	fmt.Fprintf(in.out, "Size:          %s\n", formatBytes(entry.Size))
+	if !entry.Expires.IsZero() {
+		fmt.Fprintf(in.out, "Expires:       %s\n", formatExpiry(entry.Expires, time.Now()))
+	}
+	if entry.ETag != "" {
+		fmt.Fprintf(in.out, "ETag:          %s\n", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		fmt.Fprintf(in.out, "Last-Modified: %s\n", entry.LastModified)
+	}
+	fmt.Fprintln(in.out, "")
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		indented.Reset()
+		indented.Write(body)
+	}
+	fmt.Fprintln(in.out, indented.String())
+	return nil
+}
+
+func cacheTiers(entry pokeapi.CacheEntry) string {
+	var tiers []string
+	if entry.InMemory {
+		tiers = append(tiers, "memory")
+	}
+	if entry.OnDisk {
+		tiers = append(tiers, "disk")
+	}
+	return strings.Join(tiers, ",")
+}
+
+func formatExpiry(expires, now time.Time) string {
+	if !expires.After(now) {
+		return "stale"
+	}
+	return "in " + expires.Sub(now).Round(time.Second).String()
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
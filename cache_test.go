@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	cases := []struct {
+		input    int64
+		expected string
+	}{
+		{input: 0, expected: "0 B"},
+		{input: 1023, expected: "1023 B"},
+		{input: 1536, expected: "1.5 KiB"},
+		{input: 64 << 20, expected: "64.0 MiB"},
+	}
+	for _, c := range cases {
+		if actual := formatBytes(c.input); actual != c.expected {
+			t.Errorf("formatBytes(%d): expected %q, got %q", c.input, c.expected, actual)
+		}
+	}
+}
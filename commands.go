@@ -0,0 +1,682 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+	"golang.org/x/term"
+)
+
+const (
+	categoryGeneral     = "General"
+	categoryExploration = "Exploration"
+	categoryPokemon     = "Pokemon"
+	categoryPipes       = "Pipes"
+)
+
+// session holds everything command handlers share for the lifetime of the
+// REPL. ctx is passed to API requests and is cancelled when the running
+// command is interrupted.
+type session struct {
+	ctx      context.Context
+	terminal *term.Terminal
+	api      *pokeapi.PokeAPIWrapper
+	game     *game
+	registry *registry
+	history  *history
+	config   *userConfig
+	// index is the search index, built by the first search.
+	index []record
+}
+
+func newCommandRegistry() *registry {
+	return newRegistry(
+		&command{
+			name:        "help",
+			aliases:     []string{"?"},
+			category:    categoryGeneral,
+			summary:     "Displays a help message",
+			description: "Lists all commands, or shows detailed usage of one command.",
+			args: []argSpec{
+				{name: "command", optional: true, complete: completeCommands},
+			},
+			handler: commandHelp,
+		},
+		&command{
+			name:     "exit",
+			aliases:  []string{"quit"},
+			category: categoryGeneral,
+			summary:  "Exit the Pokedex",
+			handler:  commandExit,
+		},
+		&command{
+			name:     "history",
+			category: categoryGeneral,
+			summary:  "Lists previously entered commands",
+			description: "Lists previously entered commands with their numbers, optionally only\n" +
+				"the last count of them. Re-run entry n with !n, or the last one with !!.\n" +
+				"Ctrl-R searches the history backwards.",
+			args: []argSpec{
+				{name: "count", typ: valueInt, optional: true},
+			},
+			handler: commandHistory,
+		},
+		&command{
+			name:     "alias",
+			category: categoryGeneral,
+			summary:  "Lists, defines or deletes command aliases",
+			description: "Without arguments lists all aliases. With a name, shows that alias;\n" +
+				"with a name and words, defines it, e.g. alias c catch. The first word\n" +
+				"of a command matching an alias is replaced by its words.",
+			args: []argSpec{
+				{name: "name", optional: true, complete: completeAliases},
+				{name: "expansion", variadic: true, optional: true, complete: completeCommands},
+			},
+			flags: []flagSpec{
+				{name: "delete", short: "d", typ: valueBool, usage: "delete the named alias"},
+			},
+			handler: commandAlias,
+		},
+		&command{
+			name:     "macro",
+			category: categoryGeneral,
+			summary:  "Lists, defines or deletes multi-command macros",
+			description: "Without arguments lists all macros. With a name, shows that macro.\n" +
+				"Define one with macro <name> [$1 ...] = <command>; <command>, e.g.\n" +
+				"  macro hunt $1 = explore $1; catch $1\n" +
+				"Running hunt <area> then substitutes the arguments for $1, $2, ...;\n" +
+				"$@ stands for all of them. Delete one with macro -d <name>.",
+			args: []argSpec{
+				{name: "definition", optional: true, complete: completeMacros},
+			},
+			rawArgs: true,
+			handler: commandMacro,
+		},
+		&command{
+			name:     "cache",
+			category: categoryGeneral,
+			summary:  "Inspects and manages cached API responses",
+			description: "Works on both the in-memory cache and the cache on disk:\n" +
+				"  cache stats                 sizes and hit/miss counts\n" +
+				"  cache ls [prefix]           cached URLs, e.g. cache ls pokemon/\n" +
+				"  cache show <url>            a cached response and its validators\n" +
+				"  cache evict <url|prefix>    drop a response, or all under a prefix\n" +
+				"  cache clear                 drop everything\n" +
+				"  cache warm <endpoint>       fetch every resource of an endpoint\n" +
+				"URLs may be given relative to the API, e.g. pokemon/25.",
+			args: []argSpec{
+				{name: "subcommand", complete: completeCacheSubcommands},
+				{name: "target", optional: true, complete: completeCrawlKinds},
+			},
+			handler: commandCache,
+		},
+		&command{
+			name:     "map",
+			category: categoryExploration,
+			summary:  "Displays the names of 20 locations in the Pokemon world or the next 20 locations.",
+			handler:  commandMap,
+		},
+		&command{
+			name:     "mapb",
+			category: categoryExploration,
+			summary:  "Displays the names of previous 20 locations in the Pokemon world.",
+			handler:  commandMapb,
+		},
+		&command{
+			name:     "explore",
+			category: categoryExploration,
+			summary:  "Displays the names of the Pokemon in a specified location area.",
+			description: "Lists the Pokemon that can be encountered in a location area. The\n" +
+				"area becomes the current area, whose Pokemon catch completes.",
+			args: []argSpec{
+				{name: "area", rest: true, pipe: true, complete: completeLocationAreas},
+			},
+			handler: commandExplore,
+		},
+		&command{
+			name:     "crawl",
+			category: categoryExploration,
+			summary:  "Fetches every resource of a kind, pokemon by default",
+			description: "Fetches every resource of a list endpoint such as pokemon, move or\n" +
+				"location-area, several at a time, and keeps them on disk. Resources\n" +
+				"that fail are listed at the end; running crawl again retries them\n" +
+				"without fetching the others again, also after an interrupted crawl.",
+			args: []argSpec{
+				{name: "kind", optional: true, complete: completeCrawlKinds},
+			},
+			flags: []flagSpec{
+				{name: "workers", short: "w", typ: valueInt, usage: "number of concurrent requests"},
+			},
+			handler: commandCrawl,
+		},
+		&command{
+			name:     "catch",
+			category: categoryPokemon,
+			summary:  "Catches a Pokemon in a current location area.",
+			description: "Throws a Pokeball at a Pokemon given by name or ID. Stronger Pokemon\n" +
+				"escape more often. A caught Pokemon's sprite is drawn in the terminal.",
+			args: []argSpec{
+				{name: "pokemon", rest: true, pipe: true, complete: completeAreaPokemon},
+			},
+			flags:   spriteFlags,
+			handler: commandCatch,
+		},
+		&command{
+			name:     "inspect",
+			category: categoryPokemon,
+			summary:  "Displays the details of a caught Pokemon.",
+			description: "Draws a caught Pokemon's sprite and lists its details. Sprites of\n" +
+				"game versions include red-blue, crystal, emerald and platinum.",
+			args: []argSpec{
+				{name: "pokemon", rest: true, pipe: true, complete: completeCaughtPokemon},
+			},
+			flags:   spriteFlags,
+			handler: commandInspect,
+		},
+		&command{
+			name:     "compare",
+			category: categoryPokemon,
+			summary:  "Compares Pokemon side by side",
+			description: "Shows the types, abilities, size and base stats of two or more Pokemon\n" +
+				"side by side, highlighting the best of each stat, and how well each\n" +
+				"one's types hit the others. Quote names with spaces, e.g. \"mr mime\".",
+			args: []argSpec{
+				{name: "pokemon", variadic: true, complete: completePokemon},
+			},
+			handler: commandCompare,
+		},
+		&command{
+			name:     "team",
+			category: categoryPokemon,
+			summary:  "Builds teams of up to six Pokemon and analyzes them",
+			description: "Teams may include caught Pokemon or any species, and are saved:\n" +
+				"  team ls                          all teams\n" +
+				"  team show <team>                 a team's members\n" +
+				"  team create <team> [pokemon...]  a new team\n" +
+				"  team add <team> <pokemon...>     add members\n" +
+				"  team remove <team> <pokemon...>  remove members\n" +
+				"  team delete <team>               delete a team\n" +
+				"  team analyze <team>              weaknesses, resistances, move coverage\n" +
+				"                                   and average stats, with suggestions",
+			args: []argSpec{
+				{name: "subcommand", complete: completeTeamSubcommands},
+				{name: "team", optional: true, complete: completeTeams},
+				{name: "members", variadic: true, optional: true, complete: completeTeamMembers},
+			},
+			handler: commandTeam,
+		},
+		&command{
+			name:     "pokedex",
+			category: categoryPokemon,
+			summary:  "Displays the names of all the Pokemon in your Pokedex.",
+			description: "Lists the Pokemon caught and how many species have been seen, whether\n" +
+				"found exploring or caught, e.g.\n" +
+				"  pokedex --sort -bst --group type --filter \"type=fire bst>400\"\n" +
+				"The filter is a search query and the sort field any field it can use,\n" +
+				"or caught for the order they were caught in. Given a regional pokedex\n" +
+				"such as kanto, lists it by number with the species not yet seen left as\n" +
+				"gaps; --progress shows how complete every regional pokedex and\n" +
+				"generation is.",
+			args: []argSpec{
+				{name: "dex", optional: true, complete: completePokedexes},
+			},
+			flags: []flagSpec{
+				{name: "sort", short: "s", typ: valueString, usage: "sort by id, name, caught, bst or another field, descending if prefixed with -"},
+				{name: "reverse", short: "r", typ: valueBool, usage: "sort in descending order"},
+				{name: "group", short: "g", typ: valueString, usage: "group by type or generation"},
+				{name: "filter", short: "f", typ: valueString, usage: "list only the Pokemon matching a search query"},
+				{name: "page", typ: valueInt, usage: "show page n of the listing"},
+				{name: "progress", short: "p", typ: valueBool, usage: "show completion of every pokedex and generation"},
+			},
+			handler: commandPokedex,
+		},
+		&command{
+			name:     "search",
+			category: categoryPokemon,
+			summary:  "Searches all Pokemon with a query",
+			description: "Finds the Pokemon matching every term of a query, e.g.\n" +
+				"  search type:water bst>500 gen:4 ability:swift-swim\n" +
+				"Terms are field:value, field:a,b for any of several values, !field:value\n" +
+				"to exclude, the comparisons of filter such as bst>=500, or a word the\n" +
+				"name contains. Fields are those of filter plus gen and region. The\n" +
+				"first search indexes every Pokemon, which takes a while unless they\n" +
+				"are cached, e.g. by crawl.",
+			args: []argSpec{
+				{name: "query", variadic: true, optional: true},
+			},
+			flags: []flagSpec{
+				{name: "sort", short: "s", typ: valueString, usage: "sort by a field, descending if prefixed with -"},
+				{name: "reverse", short: "r", typ: valueBool, usage: "sort in descending order"},
+				{name: "limit", short: "n", typ: valueInt, usage: "show at most n matches, 0 for all"},
+				{name: "rebuild", typ: valueBool, usage: "rebuild the index from the API"},
+			},
+			handler: commandSearch,
+		},
+		&command{
+			name:     "filter",
+			category: categoryPipes,
+			summary:  "Keeps piped items matching all conditions",
+			description: "Keeps the piped items matching every condition, e.g.\n" +
+				"  pokedex | filter type=water bst>=500 name~chu\n" +
+				"Operators are = != < <= > >= and ~ (contains). Fields include name, id,\n" +
+				"type, ability, bst, base_experience, height, weight and each base stat.",
+			args: []argSpec{
+				{name: "conditions", variadic: true},
+			},
+			handler: commandFilter,
+		},
+		&command{
+			name:     "sort",
+			category: categoryPipes,
+			summary:  "Sorts piped items by a field",
+			description: "Sorts the piped items by a field, name by default. Numbers sort\n" +
+				"numerically, e.g. pokedex | sort bst -r",
+			args: []argSpec{
+				{name: "field", optional: true},
+			},
+			flags: []flagSpec{
+				{name: "reverse", short: "r", typ: valueBool, usage: "sort in descending order"},
+			},
+			handler: commandSort,
+		},
+		&command{
+			name:        "head",
+			category:    categoryPipes,
+			summary:     "Keeps the first piped items",
+			description: "Keeps the first count piped items, 10 by default.",
+			args: []argSpec{
+				{name: "count", typ: valueInt, optional: true},
+			},
+			handler: commandHead,
+		},
+		&command{
+			name:     "count",
+			category: categoryPipes,
+			summary:  "Counts piped items",
+			handler:  commandCount,
+		},
+	)
+}
+
+func completeCommands(s *session) []string {
+	return s.registry.names()
+}
+
+func completeAliases(s *session) []string {
+	return sortedKeys(s.config.Aliases)
+}
+
+func completeMacros(s *session) []string {
+	return sortedKeys(s.config.Macros)
+}
+
+func completeLocationAreas(s *session) []string {
+	names, err := s.api.Names(s.ctx, pokeapi.KindLocationArea)
+	if err != nil {
+		return nil
+	}
+	return names
+}
+
+func completeAreaPokemon(s *session) []string {
+	if s.game.currentArea == nil {
+		return nil
+	}
+	var names []string
+	for _, encounter := range s.game.currentArea.PokemonEncounters {
+		names = append(names, encounter.Pokemon.Name)
+	}
+	return names
+}
+
+func completeCaughtPokemon(s *session) []string {
+	var names []string
+	for _, pokemon := range s.game.caught {
+		names = append(names, pokemon.Name, strconv.Itoa(pokemon.ID))
+	}
+	return names
+}
+
+func commandExit(s *session, in *invocation) error {
+	fmt.Fprintln(in.out, "Closing the Pokedex... Goodbye!")
+	return io.EOF
+}
+
+func commandHelp(s *session, in *invocation) error {
+	if in.has("command") {
+		c, ok := s.registry.lookup(in.str("command"))
+		if !ok {
+			return fmt.Errorf("unknown command %q", in.str("command"))
+		}
+		printCommandHelp(in.out, c)
+		return nil
+	}
+
+	fmt.Fprintln(in.out, "Welcome to the Pokedex!")
+	fmt.Fprintln(in.out, "Usage:")
+	for _, category := range s.registry.categories() {
+		fmt.Fprintln(in.out, "")
+		fmt.Fprintf(in.out, "%s:\n", category)
+		for _, c := range s.registry.commands {
+			if c.category == category {
+				fmt.Fprintf(in.out, "  %s: %s\n", c.name, c.summary)
+			}
+		}
+	}
+	fmt.Fprintln(in.out, "")
+	fmt.Fprintln(in.out, "Run help <command> for details on a command.")
+	return nil
+}
+
+func printCommandHelp(out io.Writer, c *command) {
+	fmt.Fprintf(out, "Usage: %s\n", c.usage())
+	fmt.Fprintln(out, "")
+	if c.description != "" {
+		fmt.Fprintln(out, c.description)
+	} else {
+		fmt.Fprintln(out, c.summary)
+	}
+	if len(c.aliases) > 0 {
+		fmt.Fprintln(out, "")
+		fmt.Fprintf(out, "Aliases: %s\n", strings.Join(c.aliases, ", "))
+	}
+	if len(c.flags) > 0 {
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Flags:")
+		for _, flag := range c.flags {
+			name := "    --" + flag.name
+			if flag.short != "" {
+				name = "-" + flag.short + ", --" + flag.name
+			}
+			fmt.Fprintf(out, "  %-20s %s\n", name, flag.usage)
+		}
+	}
+}
+
+func commandMapNextPage(s *session, in *invocation, goToNextPage bool) error {
+	page := s.game.mapPage
+	if goToNextPage {
+		if s.game.mapList != nil {
+			if s.game.mapList.Next == "" {
+				fmt.Fprintln(in.out, "you're on the last page")
+				return nil
+			}
+			page = page.Next()
+		}
+	} else {
+		if s.game.mapList == nil || s.game.mapList.Previous == "" {
+			fmt.Fprintln(in.out, "you're on the first page")
+			return nil
+		}
+		page = page.Previous()
+	}
+
+	locationAreasPage, err := s.api.List(s.ctx, pokeapi.KindLocationArea, page)
+	if err != nil {
+		return fmt.Errorf("error getting location areas page: %v", err)
+	}
+
+	s.game.mapPage = page
+	s.game.mapList = &locationAreasPage
+
+	for _, location := range locationAreasPage.Results {
+		fmt.Fprintf(in.out, "%s\n", location.Name)
+		in.emit(newRecord(location.Name))
+	}
+	return nil
+}
+
+func commandMap(s *session, in *invocation) error {
+	return commandMapNextPage(s, in, true)
+}
+
+func commandMapb(s *session, in *invocation) error {
+	return commandMapNextPage(s, in, false)
+}
+
+func commandExplore(s *session, in *invocation) error {
+	locationArea, err := s.api.LocationArea(s.ctx, in.str("area"))
+	if err != nil {
+		return fmt.Errorf("error getting location area: %v", err)
+	}
+	s.game.currentArea = &locationArea
+	// Fetching the area's Pokemon tells their species and leaves them
+	// cached for catching.
+	links := make([]pokeapi.NamedAPIResource, len(locationArea.PokemonEncounters))
+	for i, encounter := range locationArea.PokemonEncounters {
+		links[i] = encounter.Pokemon
+	}
+	pokemons, err := pokeapi.ResolveAll[pokeapi.Pokemon](s.ctx, s.api, links, 0)
+	if err != nil && s.ctx.Err() != nil {
+		return err
+	}
+	fmt.Fprintf(in.out, "Exploring %s...\n", locationArea.Name)
+	fmt.Fprintln(in.out, "Found Pokemon:")
+	for i, encounter := range locationArea.PokemonEncounters {
+		// Areas list Pokemon, some of them alternate forms of a species.
+		// One that couldn't be fetched is seen under its own name.
+		species := encounter.Pokemon.Name
+		if pokemons[i].Name != "" {
+			species = speciesName(pokemons[i])
+		}
+		s.game.see(species)
+		fmt.Fprintf(in.out, " - %s\n", encounter.Pokemon.Name)
+		in.emit(newRecord(encounter.Pokemon.Name).set("area", locationArea.Name))
+	}
+	return nil
+}
+
+// areaPokemon fetches a Pokemon of the current area through its encounter
+// link, the URL explore fetched, and any other Pokemon by name.
+func (s *session) areaPokemon(nameOrID string) (pokeapi.Pokemon, error) {
+	if area := s.game.currentArea; area != nil {
+		name := pokeapi.NormalizeName(nameOrID)
+		for _, encounter := range area.PokemonEncounters {
+			if encounter.Pokemon.Name == name {
+				return pokeapi.ResolveAs[pokeapi.Pokemon](s.ctx, s.api, encounter.Pokemon)
+			}
+		}
+	}
+	return s.api.Pokemon(s.ctx, nameOrID)
+}
+
+func commandCatch(s *session, in *invocation) error {
+	pokemon, err := s.areaPokemon(in.str("pokemon"))
+	if err != nil {
+		return fmt.Errorf("error getting pokemon: %v", err)
+	}
+	url, err := spriteURL(in, pokemon)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(in.out, "Throwing a Pokeball at %s...\n", pokemon.Name)
+
+	randInt := rand.Intn(1000)
+	pokemonCatchRate := (pokemon.BaseExperience-36)*600/(635-36+1) + 400
+	if randInt > pokemonCatchRate { // 36 - 608
+		fmt.Fprintf(in.out, "%s was caught!\n", pokemon.Name)
+		s.game.catch(pokemon)
+		in.emit(pokemonRecord(pokemon))
+		drawSprite(s, in, url)
+	} else {
+		s.game.see(speciesName(pokemon))
+		fmt.Fprintf(in.out, "%s escaped!\n", pokemon.Name)
+	}
+	return nil
+}
+
+func commandInspect(s *session, in *invocation) error {
+	pokemon, ok := findCaughtPokemon(s.game.caught, in.str("pokemon"))
+	if !ok {
+		fmt.Fprintln(in.out, "you have not caught that pokemon")
+		return nil
+	}
+	url, err := spriteURL(in, pokemon)
+	if err != nil {
+		return err
+	}
+	in.emit(pokemonRecord(pokemon))
+	drawSprite(s, in, url)
+
+	r := s.renderer(in)
+	var types []string
+	color := render.Default
+	for i, t := range pokemon.Types {
+		if i == 0 {
+			color = render.TypeColor(t.Type.Name)
+		}
+		types = append(types, r.Paint(render.TypeColor(t.Type.Name), t.Type.Name))
+	}
+	// Heights are in decimetres and weights in hectograms.
+	r.Table(in.out, render.Table{Rows: [][]string{
+		{"Name:", pokemon.Name},
+		{"ID:", strconv.Itoa(pokemon.ID)},
+		{"Height:", fmt.Sprintf("%.1f m", float64(pokemon.Height)/10)},
+		{"Weight:", fmt.Sprintf("%.1f kg", float64(pokemon.Weight)/10)},
+		{"Types:", strings.Join(types, ", ")},
+	}})
+	fmt.Fprintln(in.out, "Stats:")
+	var stats []render.Stat
+	for _, stat := range pokemon.Stats {
+		stats = append(stats, render.Stat{Name: stat.Stat.Name, Value: stat.BaseStat})
+	}
+	r.Stats(in.out, "  ", stats, maxBaseStat, color)
+	return nil
+}
+
+// maxBaseStat is the highest a base stat can be.
+const maxBaseStat = 255
+
+// findCaughtPokemon looks up a caught Pokemon by loosely spelled name or by
+// ID.
+func findCaughtPokemon(caught map[string]pokeapi.Pokemon, nameOrID string) (pokeapi.Pokemon, bool) {
+	name := pokeapi.NormalizeName(nameOrID)
+	if pokemon, ok := caught[name]; ok {
+		return pokemon, true
+	}
+	for _, pokemon := range caught {
+		if strconv.Itoa(pokemon.ID) == name {
+			return pokemon, true
+		}
+	}
+	return pokeapi.Pokemon{}, false
+}
+
+func commandHistory(s *session, in *invocation) error {
+	entries := s.history.entries
+	start := 0
+	if in.has("count") {
+		if in.int("count") < 0 {
+			return fmt.Errorf("invalid number of entries %d", in.int("count"))
+		}
+		start = max(0, len(entries)-in.int("count"))
+	}
+	for i := start; i < len(entries); i++ {
+		fmt.Fprintf(in.out, "%5d  %s\n", i+1, entries[i])
+		in.emit(newRecord(entries[i]).set("number", strconv.Itoa(i+1)))
+	}
+	return nil
+}
+
+func commandAlias(s *session, in *invocation) error {
+	name := in.str("name")
+	switch {
+	case in.bool("delete"):
+		if _, ok := s.config.Aliases[name]; !ok {
+			return fmt.Errorf("no alias named %q", name)
+		}
+		delete(s.config.Aliases, name)
+		return s.config.save()
+	case !in.has("name"):
+		for _, name := range sortedKeys(s.config.Aliases) {
+			fmt.Fprintf(in.out, "alias %s %s\n", name, s.config.Aliases[name])
+		}
+		return nil
+	case !in.has("expansion"):
+		expansion, ok := s.config.Aliases[name]
+		if !ok {
+			return fmt.Errorf("no alias named %q", name)
+		}
+		fmt.Fprintf(in.out, "alias %s %s\n", name, expansion)
+		return nil
+	}
+
+	if err := s.checkUserCommandName(name, "alias"); err != nil {
+		return err
+	}
+	s.config.Aliases[name] = joinWords(in.list("expansion"))
+	return s.config.save()
+}
+
+func commandMacro(s *session, in *invocation) error {
+	definition := in.str("definition")
+	if definition == "" {
+		for _, name := range sortedKeys(s.config.Macros) {
+			fmt.Fprintf(in.out, "macro %s %s\n", name, s.config.Macros[name])
+		}
+		return nil
+	}
+
+	name, rest, _ := strings.Cut(definition, " ")
+	if name == "-d" || name == "--delete" {
+		name = strings.TrimSpace(rest)
+		if _, ok := s.config.Macros[name]; !ok {
+			return fmt.Errorf("no macro named %q", name)
+		}
+		delete(s.config.Macros, name)
+		return s.config.save()
+	}
+	if strings.TrimSpace(rest) == "" {
+		m, ok := s.config.Macros[name]
+		if !ok {
+			return fmt.Errorf("no macro named %q", name)
+		}
+		fmt.Fprintf(in.out, "macro %s %s\n", name, m)
+		return nil
+	}
+
+	if err := s.checkUserCommandName(name, "macro"); err != nil {
+		return err
+	}
+	m, err := parseMacro(rest)
+	if err != nil {
+		return err
+	}
+	s.config.Macros[name] = m
+	return s.config.save()
+}
+
+// checkUserCommandName rejects names for a kind of user command, alias or
+// macro, that would shadow a built-in command or a user command of the
+// other kind. Redefining one of the same kind is fine.
+func (s *session) checkUserCommandName(name, kind string) error {
+	if _, ok := s.registry.lookup(name); ok {
+		return fmt.Errorf("%s is a built-in command", name)
+	}
+	if _, ok := s.config.Aliases[name]; ok && kind != "alias" {
+		return fmt.Errorf("%s is an alias", name)
+	}
+	if _, ok := s.config.Macros[name]; ok && kind != "macro" {
+		return fmt.Errorf("%s is a macro", name)
+	}
+	if strings.ContainsAny(name, " \t'\"\\;=$") {
+		return fmt.Errorf("invalid name %q", name)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+	"golang.org/x/term"
+)
+
+func TestCatchAfterExplore(t *testing.T) {
+	var mux sync.Mutex
+	requests := make(map[string]int)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		requests[r.URL.Path]++
+		mux.Unlock()
+		switch r.URL.Path {
+		case "/location-area/pallet-town":
+			fmt.Fprintf(w, `{"id":1,"name":"pallet-town","pokemon_encounters":[
+				{"pokemon":{"name":"squirtle","url":"%s/pokemon/7/"}},
+				{"pokemon":{"name":"wormadam-plant","url":"%s/pokemon/413/"}}
+			]}`, server.URL, server.URL)
+		case "/pokemon/7/":
+			fmt.Fprint(w, `{"id":7,"name":"squirtle","base_experience":63,"species":{"name":"squirtle"}}`)
+		case "/pokemon/413/":
+			fmt.Fprint(w, `{"id":413,"name":"wormadam-plant","species":{"name":"wormadam"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	api := pokeapi.NewPokeAPIWrapper(time.Minute, pokeapi.WithBaseURL(server.URL))
+	defer api.Close()
+	var out strings.Builder
+	s := &session{
+		ctx:      context.Background(),
+		terminal: term.NewTerminal(&screen{strings.NewReader(""), &out}, ""),
+		api:      api,
+		game:     newGame(),
+		registry: newCommandRegistry(),
+	}
+	s.config, _ = loadConfig("")
+
+	explore, _ := s.registry.lookup("explore")
+	if _, err := s.dispatch(explore, []string{"pallet-town"}, nil, false, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Forms are seen as their species.
+	if !s.game.seen["squirtle"] || !s.game.seen["wormadam"] || s.game.seen["wormadam-plant"] {
+		t.Errorf("expected squirtle and wormadam to be seen, got %v", s.game.seen)
+	}
+
+	before := api.Metrics()
+	catch, _ := s.registry.lookup("catch")
+	if _, err := s.dispatch(catch, []string{"--no-sprite", "squirtle"}, nil, false, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	after := api.Metrics()
+	if after.Misses != before.Misses || after.Hits != before.Hits+1 {
+		t.Errorf("expected catch to be a cache hit, got %+v before and %+v after", before, after)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if requests["/pokemon/7/"] != 1 || requests["/pokemon/squirtle"] != 0 {
+		t.Errorf("expected squirtle to be fetched once, got requests %v", requests)
+	}
+}
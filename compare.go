@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+func completePokemon(s *session) []string {
+	names, err := s.api.Names(s.ctx, pokeapi.KindPokemon)
+	if err != nil {
+		return nil
+	}
+	return names
+}
+
+func commandCompare(s *session, in *invocation) error {
+	names := in.list("pokemon")
+	if len(names) < 2 {
+		return fmt.Errorf("usage: compare <pokemon> <pokemon> [pokemon...]")
+	}
+	pokemons := make([]pokeapi.Pokemon, len(names))
+	for i, name := range names {
+		pokemon, err := s.api.Pokemon(s.ctx, name)
+		if err != nil {
+			return fmt.Errorf("error getting pokemon: %v", err)
+		}
+		pokemons[i] = pokemon
+		in.emit(pokemonRecord(pokemon))
+	}
+
+	r := s.renderer(in)
+	r.Table(in.out, comparisonTable(r, pokemons))
+
+	chart, err := s.api.TypeChart(s.ctx)
+	if err != nil {
+		fmt.Fprintf(in.out, "\nNo type matchups: %v\n", err)
+		return nil
+	}
+	fmt.Fprintln(in.out, "\nType matchups:")
+	for _, attacker := range pokemons {
+		for _, defender := range pokemons {
+			if attacker.Name == defender.Name {
+				continue
+			}
+			fmt.Fprintf(in.out, "  %s\n", describeMatchup(r, chart, attacker, defender))
+		}
+	}
+	return nil
+}
+
+// comparisonTable lays out pokemons side by side, one column each, with
+// the best value of each base stat highlighted.
+func comparisonTable(r render.Renderer, pokemons []pokeapi.Pokemon) render.Table {
+	table := render.Table{
+		Header:     []string{""},
+		RightAlign: []bool{false},
+	}
+	row := func(label string, cell func(pokeapi.Pokemon) string) {
+		cells := []string{label}
+		for _, pokemon := range pokemons {
+			cells = append(cells, cell(pokemon))
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+	for _, pokemon := range pokemons {
+		table.Header = append(table.Header, pokemon.Name)
+		table.RightAlign = append(table.RightAlign, true)
+	}
+
+	row("types", func(p pokeapi.Pokemon) string {
+		var types []string
+		for _, name := range p.TypeNames() {
+			types = append(types, r.Paint(render.TypeColor(name), name))
+		}
+		return strings.Join(types, "/")
+	})
+	row("abilities", func(p pokeapi.Pokemon) string {
+		var abilities []string
+		for _, a := range p.Abilities {
+			abilities = append(abilities, a.Ability.Name)
+		}
+		return strings.Join(abilities, ", ")
+	})
+	row("height", func(p pokeapi.Pokemon) string {
+		return fmt.Sprintf("%.1f m", float64(p.Height)/10)
+	})
+	row("weight", func(p pokeapi.Pokemon) string {
+		return fmt.Sprintf("%.1f kg", float64(p.Weight)/10)
+	})
+
+	for _, stat := range pokemons[0].Stats {
+		name := stat.Stat.Name
+		table.Rows = append(table.Rows, statRow(r, name, pokemons, func(p pokeapi.Pokemon) int {
+			return baseStat(p, name)
+		}))
+	}
+	table.Rows = append(table.Rows, statRow(r, "total", pokemons, pokeapi.Pokemon.BaseStatTotal))
+	return table
+}
+
+// statRow returns a table row of the values of pokemons with the highest
+// ones highlighted, unless they're all the same.
+func statRow(r render.Renderer, label string, pokemons []pokeapi.Pokemon, value func(pokeapi.Pokemon) int) []string {
+	values := make([]int, len(pokemons))
+	best, worst := 0, 0
+	for i, pokemon := range pokemons {
+		values[i] = value(pokemon)
+		if values[i] > values[best] {
+			best = i
+		}
+		if values[i] < values[worst] {
+			worst = i
+		}
+	}
+	cells := []string{label}
+	for _, v := range values {
+		cell := strconv.Itoa(v)
+		if v == values[best] && values[best] != values[worst] {
+			if r.Colors == nil {
+				cell = "*" + cell
+			} else {
+				cell = r.Paint(render.Green, cell)
+			}
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+func baseStat(pokemon pokeapi.Pokemon, name string) int {
+	for _, stat := range pokemon.Stats {
+		if stat.Stat.Name == name {
+			return stat.BaseStat
+		}
+	}
+	return 0
+}
+
+// describeMatchup sums up how well attacker's own types hit defender,
+// naming the most effective of them.
+func describeMatchup(r render.Renderer, chart pokeapi.TypeChart, attacker, defender pokeapi.Pokemon) string {
+	best, multiplier := "", -1.0
+	for _, name := range attacker.TypeNames() {
+		if m := chart.Effectiveness(name, defender.TypeNames()); m > multiplier {
+			best, multiplier = name, m
+		}
+	}
+	if best == "" {
+		return fmt.Sprintf("%s has no type to attack %s with", attacker.Name, defender.Name)
+	}
+	return fmt.Sprintf("%s -> %s: %s moves x%s, %s", attacker.Name, defender.Name,
+		r.Paint(render.TypeColor(best), best), formatMultiplier(multiplier), effectiveness(multiplier))
+}
+
+func formatMultiplier(m float64) string {
+	return strconv.FormatFloat(m, 'f', -1, 64)
+}
+
+// effectiveness describes a damage multiplier as the games do.
+func effectiveness(multiplier float64) string {
+	switch {
+	case multiplier == 0:
+		return "no effect"
+	case multiplier < 1:
+		return "not very effective"
+	case multiplier > 1:
+		return "super effective"
+	}
+	return "normal damage"
+}
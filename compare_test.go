@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+func TestStatRow(t *testing.T) {
+	pokemons := []pokeapi.Pokemon{{Weight: 60}, {Weight: 2350}, {Weight: 2350}}
+	weight := func(p pokeapi.Pokemon) int { return p.Weight }
+	actual := statRow(render.Renderer{}, "weight", pokemons, weight)
+	expected := []string{"weight", "60", "*2350", "*2350"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	// Nothing stands out when all are the same.
+	actual = statRow(render.Renderer{}, "weight", pokemons[1:], weight)
+	expected = []string{"weight", "2350", "2350"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestEffectiveness(t *testing.T) {
+	cases := map[float64]string{
+		0:    "no effect",
+		0.25: "not very effective",
+		1:    "normal damage",
+		4:    "super effective",
+	}
+	for multiplier, expected := range cases {
+		if actual := effectiveness(multiplier); actual != expected {
+			t.Errorf("effectiveness(%v): expected %q, got %q", multiplier, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	keyTab = '\t'
+
+	// maxListedCandidates caps how many candidates are printed when a Tab
+	// press is ambiguous, so completing "explore " doesn't dump every area.
+	maxListedCandidates = 30
+)
+
+// candidatesFunc returns the completion candidates for the word at argIndex,
+// given the words typed before it. argIndex 0 is the command name.
+type candidatesFunc func(words []string, argIndex int) []string
+
+// completer implements term.Terminal's AutoCompleteCallback. The first Tab
+// completes the current word as far as it is unambiguous and lists the
+// candidates; each further Tab cycles through them.
+type completer struct {
+	out        io.Writer
+	candidates candidatesFunc
+
+	// Cycling state, valid while the line is unchanged since the last Tab.
+	cycle    []string
+	cycleIdx int
+	head     string
+	suffix   string
+	lastLine string
+	lastPos  int
+}
+
+func newCompleter(out io.Writer, candidates candidatesFunc) *completer {
+	return &completer{out: out, candidates: candidates}
+}
+
+func (c *completer) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != keyTab {
+		c.cycle = nil
+		return "", 0, false
+	}
+
+	if c.cycle != nil && line == c.lastLine && pos == c.lastPos {
+		c.cycleIdx = (c.cycleIdx + 1) % len(c.cycle)
+		return c.replaceWord(c.cycle[c.cycleIdx], false)
+	}
+	c.cycle = nil
+
+	prefix := line[:pos]
+	c.suffix = line[pos:]
+	words := strings.Fields(prefix)
+	current := ""
+	if len(prefix) > 0 && !unicode.IsSpace(rune(prefix[len(prefix)-1])) {
+		current = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	c.head = line[:pos-len(current)]
+
+	matches := filterCandidates(c.candidates(words, len(words)), current)
+	switch len(matches) {
+	case 0:
+		return line, pos, true
+	case 1:
+		return c.replaceWord(matches[0], true)
+	}
+
+	c.listCandidates(matches)
+	c.cycle = matches
+	c.cycleIdx = -1
+	if common := commonPrefix(matches); len(common) > len(current) {
+		return c.replaceWord(common, false)
+	}
+	c.lastLine, c.lastPos = line, pos
+	return line, pos, true
+}
+
+// replaceWord swaps the word being completed for word, optionally followed
+// by a space when the completion is final.
+func (c *completer) replaceWord(word string, final bool) (string, int, bool) {
+	if final && !strings.HasPrefix(c.suffix, " ") {
+		word += " "
+	}
+	newLine := c.head + word + c.suffix
+	newPos := len(c.head) + len(word)
+	c.lastLine, c.lastPos = newLine, newPos
+	return newLine, newPos, true
+}
+
+func (c *completer) listCandidates(matches []string) {
+	shown := matches
+	if len(shown) > maxListedCandidates {
+		shown = shown[:maxListedCandidates]
+	}
+	listing := strings.Join(shown, "  ")
+	if len(matches) > len(shown) {
+		listing += fmt.Sprintf("  ... and %d more", len(matches)-len(shown))
+	}
+	fmt.Fprintln(c.out, listing)
+}
+
+func filterCandidates(candidates []string, prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	var matches []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) && !seen[candidate] {
+			seen[candidate] = true
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
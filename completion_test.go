@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestAutoComplete(t *testing.T) {
+	candidates := func(words []string, argIndex int) []string {
+		if argIndex == 0 {
+			return []string{"catch", "exit", "explore", "help"}
+		}
+		if words[0] == "catch" {
+			return []string{"pidgey", "pikachu", "rattata"}
+		}
+		return nil
+	}
+
+	cases := []struct {
+		name    string
+		line    string
+		pos     int
+		presses int
+		expect  string
+	}{
+		{name: "unique command", line: "he", pos: 2, presses: 1, expect: "help "},
+		{name: "common prefix", line: "ex", pos: 2, presses: 1, expect: "ex"},
+		{name: "cycle first", line: "ex", pos: 2, presses: 2, expect: "exit"},
+		{name: "cycle second", line: "ex", pos: 2, presses: 3, expect: "explore"},
+		{name: "cycle wraps", line: "ex", pos: 2, presses: 4, expect: "exit"},
+		{name: "argument prefix", line: "catch pi", pos: 8, presses: 1, expect: "catch pi"},
+		{name: "argument extends", line: "catch r", pos: 7, presses: 1, expect: "catch rattata "},
+		{name: "argument cycle", line: "catch pi", pos: 8, presses: 3, expect: "catch pikachu"},
+		{name: "no match", line: "catch z", pos: 7, presses: 1, expect: "catch z"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			completer := newCompleter(io.Discard, candidates)
+			line, pos := c.line, c.pos
+			for i := 0; i < c.presses; i++ {
+				var ok bool
+				line, pos, ok = completer.autoComplete(line, pos, keyTab)
+				if !ok {
+					t.Fatalf("expected Tab to be handled")
+				}
+			}
+			if line != c.expect {
+				t.Errorf("expected %q, got %q", c.expect, line)
+			}
+		})
+	}
+}
+
+func TestAutoCompleteIgnoresOtherKeys(t *testing.T) {
+	completer := newCompleter(io.Discard, func([]string, int) []string { return nil })
+	if _, _, ok := completer.autoComplete("he", 2, 'l'); ok {
+		t.Errorf("expected non-Tab key to be passed through")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	appDirName     = "pokedexcli"
+	configFileName = "config.json"
+	cacheDirName   = "responses"
+
+	// maxExpansionDepth bounds alias and macro expansion so definitions
+	// that refer to each other can't loop forever.
+	maxExpansionDepth = 16
+)
+
+// appDir returns the per-user directory the REPL keeps its files in,
+// creating it if needed.
+func appDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(configDir, appDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// cacheDir returns the per-user directory API responses are kept in.
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDirName, cacheDirName), nil
+}
+
+// userConfig holds the user's customizations, persisted as JSON. An empty
+// path keeps it in memory only.
+type userConfig struct {
+	path string
+
+	Aliases map[string]string `json:"aliases"`
+	Macros  map[string]macro  `json:"macros"`
+	// Teams holds the names of the Pokemon in each team.
+	Teams map[string][]string `json:"teams"`
+}
+
+// macro is a named command line, usually several commands joined by ";",
+// "&&" or "|". Params such as "$1" are substituted with the macro's
+// arguments; "$@" expands to all of them.
+type macro struct {
+	Params []string `json:"params"`
+	Body   string   `json:"body"`
+}
+
+func (m macro) String() string {
+	return strings.TrimSpace(strings.Join(m.Params, " ") + " = " + m.Body)
+}
+
+func loadConfig(path string) (*userConfig, error) {
+	c := &userConfig{
+		path:    path,
+		Aliases: make(map[string]string),
+		Macros:  make(map[string]macro),
+		Teams:   make(map[string][]string),
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return c, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if c.Aliases == nil {
+		c.Aliases = make(map[string]string)
+	}
+	if c.Macros == nil {
+		c.Macros = make(map[string]macro)
+	}
+	if c.Teams == nil {
+		c.Teams = make(map[string][]string)
+	}
+	return c, nil
+}
+
+func (c *userConfig) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o600)
+}
+
+// parseMacro parses a definition of the form "$1 $2 = body; body".
+func parseMacro(definition string) (macro, error) {
+	header, body, ok := strings.Cut(definition, "=")
+	if !ok || strings.TrimSpace(body) == "" {
+		return macro{}, fmt.Errorf("macro definition must look like: [$1 ...] = command; command")
+	}
+	params := strings.Fields(header)
+	for i, param := range params {
+		if param != "$"+strconv.Itoa(i+1) {
+			return macro{}, fmt.Errorf("macro parameters must be $1, $2, ... in order, got %s", param)
+		}
+	}
+	return macro{Params: params, Body: strings.TrimSpace(body)}, nil
+}
+
+// expand substitutes args into the macro body and returns its tokens.
+func (m macro) expand(args []string) ([]token, error) {
+	if len(args) < len(m.Params) {
+		return nil, fmt.Errorf("macro requires %d arguments, got %d", len(m.Params), len(args))
+	}
+
+	tokens, err := lex(m.Body)
+	if err != nil {
+		return nil, err
+	}
+	var expanded []token
+	for _, t := range tokens {
+		if t.op {
+			expanded = append(expanded, t)
+			continue
+		}
+		if t.text == "$@" {
+			for _, arg := range args {
+				expanded = append(expanded, token{text: arg})
+			}
+			continue
+		}
+		// Replace higher numbers first so $1 doesn't clobber $10.
+		for i := len(args); i >= 1; i-- {
+			t.text = strings.ReplaceAll(t.text, "$"+strconv.Itoa(i), args[i-1])
+		}
+		expanded = append(expanded, t)
+	}
+	return expanded, nil
+}
+
+// quoteWord quotes word so tokenize reads it back unchanged.
+func quoteWord(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\n'\"\\;|&") {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
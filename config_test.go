@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/term"
+)
+
+func TestParseMacro(t *testing.T) {
+	m, err := parseMacro(" $1 $2 = explore $1; catch $2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := macro{Params: []string{"$1", "$2"}, Body: "explore $1; catch $2"}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %+v, got %+v", expected, m)
+	}
+
+	for _, definition := range []string{"explore", "$2 = map", "$1 =  "} {
+		if _, err := parseMacro(definition); err == nil {
+			t.Errorf("parseMacro(%q): expected error", definition)
+		}
+	}
+}
+
+func TestMacroExpand(t *testing.T) {
+	m := macro{Params: []string{"$1"}, Body: `explore $1; catch "$1" && inspect $@`}
+	tokens, err := m.expand([]string{"mr mime", "extra"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []token{
+		{text: "explore"}, {text: "mr mime"}, {text: ";", op: true},
+		{text: "catch"}, {text: "mr mime"}, {text: "&&", op: true},
+		{text: "inspect"}, {text: "mr mime"}, {text: "extra"},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected %v, got %v", expected, tokens)
+	}
+
+	if _, err := m.expand(nil); err == nil {
+		t.Errorf("expected error for missing arguments")
+	}
+}
+
+func TestQuoteWord(t *testing.T) {
+	for _, word := range []string{"catch", "mr. mime", "it's", "a;b", "a|b", ""} {
+		words, err := tokenize(quoteWord(word))
+		if err != nil || len(words) != 1 || words[0] != word {
+			t.Errorf("quoteWord(%q) did not round-trip: %q", word, words)
+		}
+	}
+}
+
+func TestConfigSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Aliases["c"] = "catch"
+	c.Macros["hunt"] = macro{Params: []string{"$1"}, Body: "explore $1"}
+	if err := c.save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Aliases, c.Aliases) || !reflect.DeepEqual(loaded.Macros, c.Macros) {
+		t.Errorf("expected %+v, got %+v", c, loaded)
+	}
+}
+
+// newTestSession returns a session whose commands record the words they
+// were called with.
+func newTestSession(t *testing.T) (*session, *[]string) {
+	var calls []string
+	record := func(s *session, in *invocation) error {
+		calls = append(calls, strings.TrimSpace(in.command.name+" "+in.str("words")))
+		return nil
+	}
+	var out bytes.Buffer
+	s := &session{
+		ctx:      context.Background(),
+		terminal: term.NewTerminal(&screen{strings.NewReader(""), &out}, ""),
+		registry: newRegistry(
+			&command{name: "explore", args: []argSpec{{name: "words", variadic: true, optional: true}}, handler: record},
+			&command{name: "catch", args: []argSpec{{name: "words", variadic: true, optional: true}}, handler: record},
+			&command{name: "macro", args: []argSpec{{name: "definition", optional: true}}, rawArgs: true, handler: commandMacro},
+			&command{name: "alias", args: []argSpec{
+				{name: "name", optional: true},
+				{name: "expansion", variadic: true, optional: true},
+			}, flags: []flagSpec{{name: "delete", short: "d", typ: valueBool}}, handler: commandAlias},
+		),
+	}
+	s.config, _ = loadConfig("")
+	return s, &calls
+}
+
+func TestExecuteAliasesAndMacros(t *testing.T) {
+	s, calls := newTestSession(t)
+	lines := []string{
+		"alias c catch",
+		"macro hunt $1 = explore $1; c $1",
+		"hunt 'mr mime'",
+		"c pikachu",
+	}
+	for _, line := range lines {
+		if err := s.execute(line); err != nil {
+			t.Fatalf("execute(%q): unexpected error: %v", line, err)
+		}
+	}
+
+	expected := []string{"explore mr mime", "catch mr mime", "catch pikachu"}
+	if !reflect.DeepEqual(*calls, expected) {
+		t.Errorf("expected %q, got %q", expected, *calls)
+	}
+
+	if err := s.execute("alias catch explore"); err == nil {
+		t.Errorf("expected error when shadowing a built-in command")
+	}
+	if err := s.execute("alias hunt explore"); err == nil {
+		t.Errorf("expected error when an alias shadows a macro")
+	}
+	if err := s.execute("macro c $1 = explore $1"); err == nil {
+		t.Errorf("expected error when a macro shadows an alias")
+	}
+	if err := s.execute("alias c catch pikachu"); err != nil {
+		t.Errorf("unexpected error redefining an alias: %v", err)
+	}
+	s.config.Aliases["loop"] = "loop"
+	if err := s.execute("loop"); err == nil {
+		t.Errorf("expected error for a recursive alias")
+	}
+}
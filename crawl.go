@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+// maxListedCrawlErrors bounds the failures crawl prints individually.
+const maxListedCrawlErrors = 10
+
+var crawlKinds = []pokeapi.ResourceKind{
+	pokeapi.KindPokemon,
+	pokeapi.KindLocationArea,
+	pokeapi.KindMove,
+	pokeapi.KindItem,
+}
+
+// resourceSummary is the part of any resource crawl keeps for its output.
+type resourceSummary struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func completeCrawlKinds(s *session) []string {
+	kinds := make([]string, len(crawlKinds))
+	for i, kind := range crawlKinds {
+		kinds[i] = string(kind)
+	}
+	return kinds
+}
+
+func commandCrawl(s *session, in *invocation) error {
+	kind := pokeapi.KindPokemon
+	if in.has("kind") {
+		kind = pokeapi.ResourceKind(pokeapi.NormalizeName(in.str("kind")))
+	}
+	if in.has("workers") && in.int("workers") <= 0 {
+		return fmt.Errorf("invalid number of workers %d", in.int("workers"))
+	}
+	opts := pokeapi.CrawlOptions{
+		Workers:  in.int("workers"),
+		Progress: progressPrinter(in.out),
+	}
+
+	if kind != pokeapi.KindPokemon {
+		items, _, err := crawl[resourceSummary](s, in, kind, opts, "crawl "+string(kind))
+		for _, item := range items {
+			in.emit(newRecord(item.Name).set("id", strconv.Itoa(item.ID)))
+		}
+		return err
+	}
+
+	pokemons, _, err := crawl[pokeapi.Pokemon](s, in, kind, opts, "crawl "+string(kind))
+	for _, pokemon := range pokemons {
+		in.emit(pokemonRecord(pokemon))
+	}
+	if len(pokemons) > 0 {
+		minExp, maxExp := pokeapi.FindMinMaxBaseExperience(pokemons)
+		fmt.Fprintf(in.out, "Base experience ranges from %d to %d.\n", minExp, maxExp)
+	}
+	return err
+}
+
+// crawl runs a crawl and reports what it fetched and what failed, along
+// with the command to retry the failures with. It returns the resources
+// fetched and the number that failed.
+func crawl[T any](s *session, in *invocation, kind pokeapi.ResourceKind, opts pokeapi.CrawlOptions, retry string) ([]T, int, error) {
+	result, err := pokeapi.Crawl[T](s.ctx, s.api, kind, opts)
+	if err != nil && result.Items == nil && result.Errors == nil {
+		return nil, 0, err
+	}
+
+	// End the progress line.
+	fmt.Fprintln(in.out, "")
+	fmt.Fprintf(in.out, "Crawled %d %s resources", len(result.Items), kind)
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(in.out, ", %d failed", len(result.Errors))
+	}
+	fmt.Fprintln(in.out, ".")
+	for i, crawlErr := range result.Errors {
+		if i == maxListedCrawlErrors {
+			fmt.Fprintf(in.out, "  ... and %d more\n", len(result.Errors)-i)
+			break
+		}
+		fmt.Fprintf(in.out, "  - %s: %v\n", crawlErr.Resource.Name, crawlErr.Err)
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(in.out, "Run %s to retry; resources already fetched are kept.\n", retry)
+	}
+	return result.Items, len(result.Errors), err
+}
+
+// progressPrinter returns a crawl progress callback that redraws a status
+// line whenever the percentage done changes, starting a new line for each
+// kind crawled.
+func progressPrinter(out io.Writer) func(pokeapi.CrawlProgress) {
+	last := -1
+	var lastKind pokeapi.ResourceKind
+	return func(p pokeapi.CrawlProgress) {
+		if lastKind != "" && p.Kind != lastKind {
+			fmt.Fprintln(out, "")
+			last = -1
+		}
+		lastKind = p.Kind
+		percent := 100
+		if p.Total > 0 {
+			percent = p.Done * 100 / p.Total
+		}
+		if percent == last {
+			return
+		}
+		last = percent
+		fmt.Fprintf(out, "\rCrawling %s... %d/%d (%d%%)", p.Kind, p.Done, p.Total, percent)
+		if p.Failed > 0 {
+			fmt.Fprintf(out, ", %d failed", p.Failed)
+		}
+	}
+}
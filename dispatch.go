@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+var errInvalidCommand = errors.New("invalid command. Please try again")
+
+// commandError wraps an error returned by a command's handler, as opposed to
+// an error in how the command was invoked.
+type commandError struct {
+	command string
+	err     error
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("error while executing %s command: %v", e.command, e.err)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// reportedError marks an error that has already been shown to the user, so
+// nested chains such as macro bodies don't report it twice.
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+// execute runs one line of REPL input: commands joined by ";" and "&&",
+// each possibly a pipeline of commands joined by "|". Errors are reported
+// to the terminal as they happen; the returned error is the status of the
+// last pipeline run, or io.EOF when the REPL should exit.
+func (s *session) execute(line string) error {
+	tokens, err := lex(line)
+	if err != nil {
+		return s.report(err)
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	// Commands taking raw arguments get the rest of the line untouched.
+	if !tokens[0].op {
+		if c, ok := s.registry.lookup(s.resolveAlias(tokens[0].text)); ok && c.rawArgs {
+			return s.report(s.dispatchRaw(c, rawRest(line)))
+		}
+	}
+
+	links, err := parseChain(s.expandAliases(tokens, nil))
+	if err != nil {
+		return s.report(err)
+	}
+	return s.runChain(links, 0)
+}
+
+func (s *session) runChain(links []chainLink, depth int) error {
+	var status error
+	for _, link := range links {
+		if link.op == opAnd && status != nil {
+			continue
+		}
+		status = s.report(s.runPipeline(link.pipeline, depth))
+		// Ctrl-C stops the rest of the line too.
+		if status == io.EOF || s.ctx.Err() != nil {
+			return status
+		}
+	}
+	return status
+}
+
+// runPipeline runs each stage with the records emitted by the previous one
+// as its input. Only the last stage writes to the terminal.
+func (s *session) runPipeline(p pipeline, depth int) error {
+	var input []record
+	for i, words := range p {
+		last := i == len(p)-1
+		records, err := s.runStage(words, input, i > 0, last, depth)
+		if err != nil {
+			return err
+		}
+		input = records
+	}
+	return nil
+}
+
+func (s *session) runStage(words []string, input []record, piped, last bool, depth int) ([]record, error) {
+	if depth > maxExpansionDepth {
+		return nil, fmt.Errorf("macro expansion too deep at %q", words[0])
+	}
+
+	if m, ok := s.config.Macros[words[0]]; ok {
+		if piped || !last {
+			return nil, fmt.Errorf("macro %s can't be used in a pipe", words[0])
+		}
+		tokens, err := m.expand(words[1:])
+		if err != nil {
+			return nil, fmt.Errorf("macro %s: %v", words[0], err)
+		}
+		links, err := parseChain(s.expandAliases(tokens, nil))
+		if err != nil {
+			return nil, fmt.Errorf("macro %s: %v", words[0], err)
+		}
+		return nil, s.runChain(links, depth+1)
+	}
+
+	c, ok := s.registry.lookup(words[0])
+	if !ok {
+		return nil, errInvalidCommand
+	}
+	out := io.Writer(s.terminal)
+	if !last {
+		out = io.Discard
+	}
+	return s.dispatch(c, words[1:], input, piped, out)
+}
+
+// dispatch binds the arguments to the command and runs its handler. A
+// command reading piped records whose pipe argument wasn't given runs once
+// per record instead.
+func (s *session) dispatch(c *command, args []string, input []record, piped bool, out io.Writer) ([]record, error) {
+	if arg, ok := c.pipeArg(); ok && piped {
+		if in, err := c.bind(args); err != nil || !in.has(arg.name) {
+			var records []record
+			for _, r := range input {
+				emitted, err := s.invoke(c, append(append([]string{}, args...), "--", r.name), nil, false, out)
+				records = append(records, emitted...)
+				if err != nil {
+					return records, err
+				}
+			}
+			return records, nil
+		}
+	}
+	return s.invoke(c, args, input, piped, out)
+}
+
+func (s *session) invoke(c *command, args []string, input []record, piped bool, out io.Writer) ([]record, error) {
+	in, err := c.bind(args)
+	if err != nil {
+		return nil, err
+	}
+	in.out = out
+	in.input = input
+	in.piped = piped
+	err = s.handle(c, in)
+	return in.records, err
+}
+
+func (s *session) dispatchRaw(c *command, raw string) error {
+	in := &invocation{
+		command: c,
+		out:     s.terminal,
+		args:    map[string]any{},
+		flags:   map[string]any{},
+	}
+	if raw != "" {
+		in.args[c.args[0].name] = raw
+	}
+	return s.handle(c, in)
+}
+
+func (s *session) handle(c *command, in *invocation) error {
+	err := c.handler(s, in)
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return &commandError{command: c.name, err: err}
+}
+
+// report shows err to the user unless it has been already and returns it
+// marked as reported.
+func (s *session) report(err error) error {
+	var reported *reportedError
+	if err == nil || err == io.EOF || errors.As(err, &reported) {
+		return err
+	}
+
+	var cmdErr *commandError
+	switch {
+	case errors.As(err, &cmdErr):
+		fmt.Fprintf(s.terminal,
+			"Error while executing %s command: %v\n",
+			cmdErr.command,
+			cmdErr.err,
+		)
+	case err == errInvalidCommand:
+		fmt.Fprintln(s.terminal, "Invalid command. Please try again.")
+	default:
+		fmt.Fprintf(s.terminal, "Error: %v\n", err)
+	}
+	return &reportedError{err: err}
+}
+
+// expandAliases replaces user aliases in command position with the tokens
+// they stand for. An alias is not expanded again within its own expansion,
+// so "alias ls map" followed by "alias map ls" can't loop.
+func (s *session) expandAliases(tokens []token, seen map[string]bool) []token {
+	var expanded []token
+	for i, t := range tokens {
+		commandPosition := i == 0 || tokens[i-1].op
+		alias, ok := s.config.Aliases[t.text]
+		if t.op || !commandPosition || !ok || seen[t.text] {
+			expanded = append(expanded, t)
+			continue
+		}
+		aliased, err := lex(alias)
+		if err != nil || len(aliased) == 0 {
+			expanded = append(expanded, t)
+			continue
+		}
+
+		inner := map[string]bool{t.text: true}
+		for name := range seen {
+			inner[name] = true
+		}
+		expanded = append(expanded, s.expandAliases(aliased, inner)...)
+	}
+	return expanded
+}
+
+// resolveAlias follows user aliases for a command name.
+func (s *session) resolveAlias(name string) string {
+	for i := 0; i < maxExpansionDepth; i++ {
+		expansion, ok := s.config.Aliases[name]
+		if !ok {
+			return name
+		}
+		words, err := tokenize(expansion)
+		if err != nil || len(words) == 0 {
+			return name
+		}
+		name = words[0]
+	}
+	return name
+}
+
+// rawRest returns line without its first word.
+func rawRest(line string) string {
+	line = strings.TrimSpace(line)
+	i := strings.IndexFunc(line, unicode.IsSpace)
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(line[i:])
+}
+
+func joinWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = quoteWord(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// candidates completes command names, including user aliases and macros,
+// and the arguments of the command an alias stands for. Only the words of
+// the last command in a chain or pipe are considered.
+func (s *session) candidates(words []string, argIndex int) []string {
+	for i := len(words) - 1; i >= 0; i-- {
+		if words[i] == opSequence || words[i] == opAnd || words[i] == opPipe {
+			words = words[i+1:]
+			argIndex -= i + 1
+			break
+		}
+	}
+
+	if argIndex == 0 {
+		names := s.registry.names()
+		names = append(names, sortedKeys(s.config.Aliases)...)
+		return append(names, sortedKeys(s.config.Macros)...)
+	}
+	resolved := append([]string{s.resolveAlias(words[0])}, words[1:]...)
+	return s.registry.candidates(s, resolved, argIndex)
+}
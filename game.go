@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+// game is the player's progress: where they are on the map and the
+// Pokemon they have seen and caught.
+type game struct {
+	// mapPage is the page of location areas last shown by map or mapb and
+	// mapList its contents; mapList is nil before the first map.
+	mapPage pokeapi.Page
+	mapList *pokeapi.NamedAPIResourceList
+
+	// currentArea is the location area most recently explored, if any.
+	currentArea *pokeapi.LocationArea
+
+	caught map[string]pokeapi.Pokemon
+	// caughtOrder numbers the Pokemon caught, from 1, in the order they
+	// were first caught.
+	caughtOrder map[string]int
+	// seen holds the species found exploring or caught, by name.
+	seen map[string]bool
+}
+
+func newGame() *game {
+	return &game{
+		mapPage:     pokeapi.FirstPage(),
+		caught:      make(map[string]pokeapi.Pokemon),
+		caughtOrder: make(map[string]int),
+		seen:        make(map[string]bool),
+	}
+}
+
+// see records that a species was found.
+func (g *game) see(species string) {
+	g.seen[species] = true
+}
+
+func (g *game) catch(pokemon pokeapi.Pokemon) {
+	if _, ok := g.caughtOrder[pokemon.Name]; !ok {
+		g.caughtOrder[pokemon.Name] = len(g.caughtOrder) + 1
+	}
+	g.caught[pokemon.Name] = pokemon
+	g.see(speciesName(pokemon))
+}
+
+// caughtSpecies returns the names of the species caught.
+func (g *game) caughtSpecies() map[string]bool {
+	species := make(map[string]bool, len(g.caught))
+	for _, pokemon := range g.caught {
+		species[speciesName(pokemon)] = true
+	}
+	return species
+}
+
+func speciesName(pokemon pokeapi.Pokemon) string {
+	if pokemon.Species.Name != "" {
+		return pokemon.Species.Name
+	}
+	return pokemon.Name
+}
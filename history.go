@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+const (
+	historyFileName  = "history"
+	maxHistoryLength = 1000
+
+	// terminalHistoryLength is the size of term.Terminal's internal ring
+	// buffer used for Up/Down navigation.
+	terminalHistoryLength = 100
+
+	keyCtrlG = 7
+	keyCtrlR = 18
+)
+
+// history is the list of lines entered at the REPL, persisted to a file so
+// it survives across sessions. An empty path keeps it in memory only.
+type history struct {
+	path    string
+	entries []string
+	max     int
+}
+
+func loadHistory(path string, max int) (*history, error) {
+	h := &history{path: path, max: max}
+	if path == "" {
+		return h, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		h.add(scanner.Text())
+	}
+	return h, scanner.Err()
+}
+
+// add appends line unless it is empty or repeats the previous entry. It
+// reports whether the line was added.
+func (h *history) add(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == line) {
+		return false
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.max {
+		h.entries = h.entries[len(h.entries)-h.max:]
+	}
+	return true
+}
+
+func (h *history) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data := strings.Join(h.entries, "\n") + "\n"
+	return os.WriteFile(h.path, []byte(data), 0o600)
+}
+
+// expand resolves "!!" to the previous entry and "!n" to entry number n as
+// shown by the history command. Other lines are returned unchanged.
+func (h *history) expand(line string) (string, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "!") || len(line) == 1 {
+		return line, nil
+	}
+	if line == "!!" {
+		if len(h.entries) == 0 {
+			return "", fmt.Errorf("!!: event not found")
+		}
+		return h.entries[len(h.entries)-1], nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(h.entries) {
+		return "", fmt.Errorf("%s: event not found", line)
+	}
+	return h.entries[n-1], nil
+}
+
+// searchBackward returns the index of the newest entry at or before from
+// that contains query.
+func (h *history) searchBackward(query string, from int) (int, bool) {
+	for i := min(from, len(h.entries)-1); i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// seedTerminalHistory replays the newest entries through the terminal with
+// output discarded, since term.Terminal offers no other way to fill the
+// history it uses for the Up and Down keys.
+func seedTerminalHistory(s *screen, terminal *term.Terminal, entries []string) {
+	if len(entries) > terminalHistoryLength {
+		entries = entries[len(entries)-terminalHistoryLength:]
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	reader, writer := s.Reader, s.Writer
+	defer func() { s.Reader, s.Writer = reader, writer }()
+	s.Reader = strings.NewReader(strings.Join(entries, "\r") + "\r")
+	s.Writer = io.Discard
+	for range entries {
+		if _, err := terminal.ReadLine(); err != nil {
+			return
+		}
+	}
+}
+
+// historySearch implements Ctrl-R reverse incremental search on top of
+// term.Terminal's AutoCompleteCallback. While active, typed characters
+// extend the query, Ctrl-R jumps to the next older match and Ctrl-G
+// restores the original line; any other key accepts the match.
+type historySearch struct {
+	terminal *term.Terminal
+	history  *history
+	prompt   string
+
+	active   bool
+	query    string
+	matchIdx int
+	original string
+	lastLine string
+}
+
+func newHistorySearch(terminal *term.Terminal, h *history, prompt string) *historySearch {
+	return &historySearch{terminal: terminal, history: h, prompt: prompt}
+}
+
+func (s *historySearch) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if !s.active {
+		if key != keyCtrlR {
+			return "", 0, false
+		}
+		s.active = true
+		s.query = ""
+		s.matchIdx = len(s.history.entries)
+		s.original = line
+		s.lastLine = line
+		s.showPrompt(true)
+		return line, pos, true
+	}
+
+	// The terminal handles keys like Backspace without consulting us, so a
+	// changed line means the user is editing the match.
+	if line != s.lastLine {
+		s.stop()
+		return "", 0, false
+	}
+
+	from := s.matchIdx
+	switch {
+	case key == keyCtrlR:
+		from--
+	case key == keyCtrlG:
+		s.stop()
+		return s.original, len(s.original), true
+	case unicode.IsPrint(key):
+		s.query += string(key)
+	default:
+		s.stop()
+		return "", 0, false
+	}
+
+	idx, found := s.history.searchBackward(s.query, from)
+	if found {
+		s.matchIdx = idx
+		line = s.history.entries[idx]
+		pos = strings.Index(line, s.query)
+	}
+	s.lastLine = line
+	s.showPrompt(found)
+	return line, pos, true
+}
+
+// reset leaves search mode after a line has been submitted.
+func (s *historySearch) reset() {
+	if s.active {
+		s.active = false
+		s.terminal.SetPrompt(s.prompt)
+	}
+}
+
+func (s *historySearch) stop() {
+	s.reset()
+	s.repaint()
+}
+
+func (s *historySearch) showPrompt(found bool) {
+	label := "reverse-i-search"
+	if !found {
+		label = "failing " + label
+	}
+	s.terminal.SetPrompt(fmt.Sprintf("(%s)`%s': ", label, s.query))
+	s.repaint()
+}
+
+// repaint redraws the prompt and current line; writing nothing to the
+// terminal mid-line makes it clear and redraw both.
+func (s *historySearch) repaint() {
+	s.terminal.Write(nil)
+}
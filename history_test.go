@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/term"
+)
+
+func TestHistoryAdd(t *testing.T) {
+	h := &history{max: 3}
+	for _, line := range []string{"map", "map", " ", "explore a", "catch b", "map"} {
+		h.add(line)
+	}
+	expected := []string{"explore a", "catch b", "map"}
+	if !reflect.DeepEqual(h.entries, expected) {
+		t.Errorf("expected %v, got %v", expected, h.entries)
+	}
+}
+
+func TestHistorySaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), historyFileName)
+	h, err := loadHistory(path, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.add("map")
+	h.add("explore canalave-city-area")
+	if err := h.save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := loadHistory(path, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.entries, h.entries) {
+		t.Errorf("expected %v, got %v", h.entries, loaded.entries)
+	}
+}
+
+func TestHistoryExpand(t *testing.T) {
+	h := &history{max: 10, entries: []string{"map", "explore a", "catch b"}}
+	cases := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{input: "!!", expected: "catch b"},
+		{input: "!1", expected: "map"},
+		{input: "!2", expected: "explore a"},
+		{input: "!4", wantErr: true},
+		{input: "!x", wantErr: true},
+		{input: "pokedex", expected: "pokedex"},
+	}
+
+	for _, c := range cases {
+		actual, err := h.expand(c.input)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("expand(%q): expected error", c.input)
+			}
+			continue
+		}
+		if err != nil || actual != c.expected {
+			t.Errorf("expand(%q): expected %q, got %q (%v)", c.input, c.expected, actual, err)
+		}
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	h := &history{max: 10, entries: []string{"catch pikachu", "map", "catch pidgey"}}
+	terminal := term.NewTerminal(&screen{strings.NewReader(""), io.Discard}, "> ")
+	search := newHistorySearch(terminal, h, "> ")
+
+	line, pos := "", 0
+	for _, key := range []rune{keyCtrlR, 'c', 'a'} {
+		line, pos, _ = search.autoComplete(line, pos, key)
+	}
+	if line != "catch pidgey" || pos != 0 {
+		t.Errorf("expected newest match, got %q at %d", line, pos)
+	}
+
+	line, _, _ = search.autoComplete(line, pos, keyCtrlR)
+	if line != "catch pikachu" {
+		t.Errorf("expected older match, got %q", line)
+	}
+
+	line, _, ok := search.autoComplete(line, pos, keyCtrlG)
+	if !ok || line != "" || search.active {
+		t.Errorf("expected Ctrl-G to restore the original line, got %q", line)
+	}
+}
+
+func TestSeedTerminalHistory(t *testing.T) {
+	rw := &screen{strings.NewReader("\x1b[A\r"), io.Discard}
+	terminal := term.NewTerminal(rw, "> ")
+	seedTerminalHistory(rw, terminal, []string{"map", "explore a"})
+
+	line, err := terminal.ReadLine()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if line != "explore a" {
+		t.Errorf("expected Up to recall %q, got %q", "explore a", line)
+	}
+}
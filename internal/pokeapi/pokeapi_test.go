@@ -1 +0,0 @@
-package pokeapi
\ No newline at end of file
@@ -0,0 +1,56 @@
+package pokecache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// codec says how an entry's value is stored.
+type codec uint8
+
+const (
+	codecNone codec = iota
+	codecGzip
+)
+
+func (c codec) String() string {
+	if c == codecGzip {
+		return "gzip"
+	}
+	return "none"
+}
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// compress gzips val if that makes it smaller.
+func compress(val []byte) ([]byte, codec) {
+	var buf bytes.Buffer
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(val); err != nil {
+		return val, codecNone
+	}
+	if err := w.Close(); err != nil || buf.Len() >= len(val) {
+		return val, codecNone
+	}
+	return buf.Bytes(), codecGzip
+}
+
+func decompress(val []byte, c codec) ([]byte, error) {
+	if c != codecGzip {
+		return val, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(val))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
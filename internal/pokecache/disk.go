@@ -0,0 +1,157 @@
+package pokecache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DiskCache keeps entries as files in a directory so they outlive the
+// process. Each file holds the key on its first line followed by the value.
+// Entries never expire.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a cache storing its files in dir, which is created
+// when the first entry is added.
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+func (d *DiskCache) Add(key string, val []byte) error {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so a crash never leaves a partial
+	// entry behind.
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(key + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(val); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path(key))
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	storedKey, val, ok := bytes.Cut(data, []byte("\n"))
+	if !ok || string(storedKey) != key {
+		return nil, false
+	}
+	return val, true
+}
+
+// DiskEntry describes an entry in a DiskCache.
+type DiskEntry struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
+
+// Entries describes every entry in the cache, reading only their keys.
+func (d *DiskCache) Entries() ([]DiskEntry, error) {
+	files, err := d.files()
+	if err != nil {
+		return nil, err
+	}
+	var entries []DiskEntry
+	for _, file := range files {
+		key, err := readKey(filepath.Join(d.dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, DiskEntry{
+			Key:      key,
+			Size:     info.Size() - int64(len(key)) - 1,
+			Modified: info.ModTime(),
+		})
+	}
+	return entries, nil
+}
+
+// Delete removes the entry for key, if any.
+func (d *DiskCache) Delete(key string) error {
+	err := os.Remove(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Clear removes every entry.
+func (d *DiskCache) Clear() error {
+	files, err := d.files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(filepath.Join(d.dir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// files lists the entry files in the cache directory, skipping anything
+// else that may be there.
+func (d *DiskCache) files() ([]os.DirEntry, error) {
+	dirEntries, err := os.ReadDir(d.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []os.DirEntry
+	for _, entry := range dirEntries {
+		if name := entry.Name(); entry.Type().IsRegular() && len(name) == 2*sha256.Size {
+			if _, err := hex.DecodeString(name); err == nil {
+				files = append(files, entry)
+			}
+		}
+	}
+	return files, nil
+}
+
+func readKey(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	key, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(key, "\n"), nil
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
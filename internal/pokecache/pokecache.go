@@ -1,72 +1,456 @@
 package pokecache
 
 import (
+	"container/list"
+	"context"
+	"hash/maphash"
 	"sync"
 	"time"
 )
 
+// Cache is an in-memory cache whose entries expire after a TTL, by default
+// the interval given to NewCache. It can also be bounded in bytes and
+// entries, in which case the least recently used entries are evicted to
+// make room. A goroutine removes expired entries every interval until the
+// cache is closed.
+//
+// Values derived from an entry, such as what it decodes to, can be
+// attached to it, so that they are dropped with it and count against the
+// budget.
 type Cache struct {
-	cache map[string]cacheEntry
-	mux   *sync.RWMutex
+	cache map[string]*list.Element
+	// lru orders entries from most to least recently used.
+	lru *list.List
+	mux *sync.Mutex
+
+	ttl        time.Duration
+	sliding    bool
+	maxBytes   int
+	maxEntries int
+	// compressAbove is the value size from which values are compressed,
+	// zero to never compress.
+	compressAbove int
+	stats         Stats
+	// seed hashes values, to tell whether an entry still holds the value
+	// something was attached for.
+	seed maphash.Seed
+
+	ctx   context.Context
+	close context.CancelFunc
 }
 
 type cacheEntry struct {
-	createAt time.Time
-	val      []byte
+	key       string
+	createAt  time.Time
+	expiresAt time.Time
+	ttl       time.Duration
+	val       []byte
+	codec     codec
+	// rawSize is the length of the value before compression, and sum its
+	// hash.
+	rawSize int
+	sum     uint64
+	// attached holds the values attached to the entry by tag, and
+	// attachedSize their total size.
+	attached     map[any]attachment
+	attachedSize int
+}
+
+// attachment is a value attached to an entry and its estimated size.
+type attachment struct {
+	v    any
+	size int
+}
+
+func (e *cacheEntry) expired(now time.Time) bool {
+	return !now.Before(e.expiresAt)
+}
+
+// size is what an entry counts against the byte budget.
+func (e *cacheEntry) size() int {
+	return len(e.key) + len(e.val)
+}
+
+// Stats describes a cache's contents and how it has been used.
+type Stats struct {
+	Entries int
+	// Bytes is the size of the keys and values as stored, UncompressedBytes
+	// what it would be without compression.
+	Bytes             int
+	UncompressedBytes int
+	CompressedEntries int
+	// Attached counts the values attached to entries and AttachedBytes
+	// their estimated size, which counts against the byte budget as well.
+	Attached      int
+	AttachedBytes int
+	Hits          int64
+	Misses        int64
+	// Evictions counts entries removed to stay within the budget, as
+	// opposed to expired ones.
+	Evictions int64
+	Expired   int64
+}
+
+// Option configures a Cache.
+type Option func(*Cache)
+
+// WithMaxBytes bounds the total size of keys and values held. Values
+// larger than the budget are not cached at all.
+func WithMaxBytes(n int) Option {
+	return func(c *Cache) {
+		c.maxBytes = n
+	}
+}
+
+// WithCompression gzips values of at least threshold bytes, where that
+// makes them smaller. Get returns them decompressed.
+func WithCompression(threshold int) Option {
+	return func(c *Cache) {
+		c.compressAbove = threshold
+	}
+}
+
+// WithSlidingExpiration makes each Get of an entry extend its life by its
+// TTL, so entries in use don't expire.
+func WithSlidingExpiration() Option {
+	return func(c *Cache) {
+		c.sliding = true
+	}
+}
+
+// WithContext stops the cache's reaping goroutine when ctx is done, as
+// Close does.
+func WithContext(ctx context.Context) Option {
+	return func(c *Cache) {
+		c.ctx = ctx
+	}
 }
 
-func NewCache(interval time.Duration) *Cache {
+// WithMaxEntries bounds the number of entries held.
+func WithMaxEntries(n int) Option {
+	return func(c *Cache) {
+		c.maxEntries = n
+	}
+}
+
+func NewCache(interval time.Duration, opts ...Option) *Cache {
 	cache := &Cache{
-		cache: make(map[string]cacheEntry),
-		mux:   &sync.RWMutex{},
+		cache: make(map[string]*list.Element),
+		lru:   list.New(),
+		mux:   &sync.Mutex{},
+		ttl:   interval,
+		ctx:   context.Background(),
+		seed:  maphash.MakeSeed(),
+	}
+	for _, opt := range opts {
+		opt(cache)
 	}
+	cache.ctx, cache.close = context.WithCancel(cache.ctx)
 	go cache.reapLoop(interval)
 	return cache
 }
 
+// Close stops the cache's reaping goroutine. The cache stays usable, but
+// expired entries are only removed when they are looked up.
+func (c *Cache) Close() {
+	c.close()
+}
+
+// Add caches val under key for the cache's default TTL.
 func (c *Cache) Add(key string, val []byte) {
+	c.AddWithTTL(key, val, c.ttl)
+}
+
+// AddWithTTL caches val under key until ttl has passed.
+func (c *Cache) AddWithTTL(key string, val []byte, ttl time.Duration) {
+	stored, codec := val, codecNone
+	if c.compressAbove > 0 && len(val) >= c.compressAbove {
+		stored, codec = compress(val)
+	}
+	sum := maphash.Bytes(c.seed, val)
+
 	c.mux.Lock()
 	defer c.mux.Unlock()
-	c.cache[key] = cacheEntry{
-		createAt: time.Now(),
-		val:      val,
+	if elem, ok := c.cache[key]; ok {
+		c.remove(elem)
+	}
+	now := time.Now()
+	entry := &cacheEntry{
+		key:       key,
+		createAt:  now,
+		expiresAt: now.Add(ttl),
+		ttl:       ttl,
+		val:       stored,
+		codec:     codec,
+		rawSize:   len(val),
+		sum:       sum,
 	}
+	if c.maxBytes > 0 && entry.size() > c.maxBytes {
+		return
+	}
+	c.cache[key] = c.lru.PushFront(entry)
+	c.stats.Entries++
+	c.stats.Bytes += entry.size()
+	c.stats.UncompressedBytes += len(key) + entry.rawSize
+	if codec != codecNone {
+		c.stats.CompressedEntries++
+	}
+	c.evict()
 }
 
 func (c *Cache) Get(key string) ([]byte, bool) {
-	c.mux.RLock()
-	defer c.mux.RUnlock()
-	entry, ok := c.cache[key]
+	entry, ok := c.use(key)
 	if !ok {
 		return nil, false
 	}
-	return entry.val, true
+	val, err := decompress(entry.val, entry.codec)
+	if err != nil {
+		c.Delete(key)
+		return nil, false
+	}
+	return val, true
 }
 
-func (c *Cache) reapLoop(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	for range ticker.C {
-		c.reap(interval)
+// use looks up the entry for key, counting a hit or miss and marking it
+// as recently used.
+func (c *Cache) use(key string) (*cacheEntry, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	elem, ok := c.cache[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	now := time.Now()
+	if entry.expired(now) {
+		c.remove(elem)
+		c.stats.Expired++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.touch(elem, now)
+	return entry, true
+}
+
+// touch counts a hit of elem and marks it as recently used.
+func (c *Cache) touch(elem *list.Element, now time.Time) {
+	c.stats.Hits++
+	c.lru.MoveToFront(elem)
+	if entry := elem.Value.(*cacheEntry); c.sliding {
+		entry.expiresAt = now.Add(entry.ttl)
+	}
+}
+
+// Attach attaches v, estimated at size bytes, to the entry for key under
+// tag, replacing any value attached under tag before. val is the value v
+// was derived from: if the entry no longer holds it, having been replaced
+// in the meantime, nothing is attached. Attach reports whether v was.
+func (c *Cache) Attach(key string, val []byte, tag, v any, size int) bool {
+	sum := maphash.Bytes(c.seed, val)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	elem, ok := c.cache[key]
+	if !ok {
+		return false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if entry.expired(time.Now()) || entry.sum != sum || entry.rawSize != len(val) {
+		return false
+	}
+	c.detach(entry, tag)
+	if c.maxBytes > 0 && entry.size()+entry.attachedSize+size > c.maxBytes {
+		return false
+	}
+	if entry.attached == nil {
+		entry.attached = make(map[any]attachment)
+	}
+	entry.attached[tag] = attachment{v, size}
+	entry.attachedSize += size
+	c.stats.Attached++
+	c.stats.AttachedBytes += size
+	c.evict()
+	return true
+}
+
+// Attached returns the value attached under tag to the entry for key. If
+// there is one, the entry is marked as used as by Get.
+func (c *Cache) Attached(key string, tag any) (any, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	elem, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	a, ok := entry.attached[tag]
+	now := time.Now()
+	if !ok || entry.expired(now) {
+		return nil, false
+	}
+	c.touch(elem, now)
+	return a.v, true
+}
+
+// GetOrLoad returns the value cached under key, or calls load and caches
+// its result for the default TTL if there is none. Errors from load are
+// returned and not cached. Concurrent calls for a missing key may each
+// call load.
+func (c *Cache) GetOrLoad(key string, load func() ([]byte, error)) ([]byte, error) {
+	if val, ok := c.Get(key); ok {
+		return val, nil
+	}
+	val, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.Add(key, val)
+	return val, nil
+}
+
+// EntryInfo describes a cached entry. Size is as stored, possibly
+// compressed with Codec.
+type EntryInfo struct {
+	Key     string
+	Size    int
+	Codec   string
+	Added   time.Time
+	Expires time.Time
+}
+
+func (e *cacheEntry) info() EntryInfo {
+	return EntryInfo{
+		Key:     e.key,
+		Size:    e.size(),
+		Codec:   e.codec.String(),
+		Added:   e.createAt,
+		Expires: e.expiresAt,
+	}
+}
+
+// Entries describes the entries that haven't expired, most recently used
+// first.
+func (c *Cache) Entries() []EntryInfo {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	now := time.Now()
+	var entries []EntryInfo
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*cacheEntry)
+		if entry.expired(now) {
+			continue
+		}
+		entries = append(entries, entry.info())
+	}
+	return entries
+}
+
+// Peek returns the entry for key like Get, but without counting it as a
+// use.
+func (c *Cache) Peek(key string) ([]byte, EntryInfo, bool) {
+	c.mux.Lock()
+	elem, ok := c.cache[key]
+	if !ok || elem.Value.(*cacheEntry).expired(time.Now()) {
+		c.mux.Unlock()
+		return nil, EntryInfo{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	c.mux.Unlock()
+
+	val, err := decompress(entry.val, entry.codec)
+	if err != nil {
+		return nil, EntryInfo{}, false
+	}
+	return val, entry.info(), true
+}
+
+// Delete removes the entry for key and reports whether there was one.
+func (c *Cache) Delete(key string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	elem, ok := c.cache[key]
+	if ok {
+		c.remove(elem)
+	}
+	return ok
+}
+
+// Clear removes all entries.
+func (c *Cache) Clear() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for _, elem := range c.cache {
+		c.remove(elem)
+	}
+}
+
+// Stats returns the cache's current size and counters.
+func (c *Cache) Stats() Stats {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.stats
+}
+
+// evict removes least recently used entries until the cache is within its
+// budget.
+func (c *Cache) evict() {
+	for c.lru.Len() > 0 &&
+		(c.maxBytes > 0 && c.stats.Bytes+c.stats.AttachedBytes > c.maxBytes ||
+			c.maxEntries > 0 && c.stats.Entries > c.maxEntries) {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.cache, entry.key)
+	c.stats.Entries--
+	c.stats.Bytes -= entry.size()
+	c.stats.UncompressedBytes -= len(entry.key) + entry.rawSize
+	if entry.codec != codecNone {
+		c.stats.CompressedEntries--
+	}
+	for tag := range entry.attached {
+		c.detach(entry, tag)
 	}
 }
 
-func (c *Cache) reap(interval time.Duration) {
-	timeAgo := time.Now().Add(-interval)
-	var keysToDelete []string
+// detach drops the value attached to entry under tag, if any.
+func (c *Cache) detach(entry *cacheEntry, tag any) {
+	a, ok := entry.attached[tag]
+	if !ok {
+		return
+	}
+	delete(entry.attached, tag)
+	entry.attachedSize -= a.size
+	c.stats.Attached--
+	c.stats.AttachedBytes -= a.size
+}
 
-	c.mux.RLock()
-	for k, v := range c.cache {
-		if v.createAt.Before(timeAgo) {
-			keysToDelete = append(keysToDelete, k)
+func (c *Cache) reapLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.reap()
+		case <-c.ctx.Done():
+			return
 		}
 	}
-	c.mux.RUnlock()
+}
+
+func (c *Cache) reap() {
+	now := time.Now()
 
-	if len(keysToDelete) > 0 {
-		c.mux.Lock()
-		defer c.mux.Unlock()
-		for _, k := range keysToDelete {
-			delete(c.cache, k)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for _, elem := range c.cache {
+		if elem.Value.(*cacheEntry).expired(now) {
+			c.remove(elem)
+			c.stats.Expired++
 		}
 	}
-}
\ No newline at end of file
+}
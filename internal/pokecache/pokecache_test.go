@@ -1,7 +1,12 @@
 package pokecache
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -25,6 +30,7 @@ func TestAddGet(t *testing.T) {
 	for i, c := range cases {
 		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
 			cache := NewCache(interval)
+			defer cache.Close()
 			cache.Add(c.key, c.val)
 			val, ok := cache.Get(c.key)
 			if !ok {
@@ -43,6 +49,7 @@ func TestReapLoop(t *testing.T) {
 	const baseTime = 5 * time.Millisecond
 	const waitTime = baseTime + 5*time.Millisecond
 	cache := NewCache(baseTime)
+	defer cache.Close()
 	cache.Add("https://example.com", []byte("testdata"))
 
 	_, ok := cache.Get("https://example.com")
@@ -64,6 +71,7 @@ func TestReapTwice(t *testing.T) {
 	const baseTime = 5 * time.Millisecond
 	const waitTime = baseTime + 5*time.Millisecond
 	cache := NewCache(baseTime)
+	defer cache.Close()
 	cache.Add("https://example.com", []byte("testdata"))
 
 	_, ok := cache.Get("https://example.com")
@@ -95,4 +103,368 @@ func TestReapTwice(t *testing.T) {
 		t.Errorf("expected to not find key")
 		return
 	}
-}
\ No newline at end of file
+}
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewDiskCache(dir)
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+	}
+	if err := cache.Add("https://example.com", []byte("testdata")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new cache over the same directory sees the entry.
+	val, ok := NewDiskCache(dir).Get("https://example.com")
+	if !ok || string(val) != "testdata" {
+		t.Errorf("expected to find testdata, got %q", val)
+	}
+	if _, ok := cache.Get("https://example.com/path"); ok {
+		t.Errorf("expected to not find key")
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	cache := NewCache(5*time.Second, WithMaxEntries(2))
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("2"))
+	cache.Get("a") // b is now least recently used
+	cache.Add("c", []byte("3"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected to find %s", key)
+		}
+	}
+
+	stats := cache.Stats()
+	expected := Stats{Entries: 2, Bytes: 4, UncompressedBytes: 4, Hits: 3, Misses: 1, Evictions: 1}
+	if stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	cache := NewCache(5*time.Second, WithMaxBytes(10))
+	defer cache.Close()
+	cache.Add("a", []byte("1234"))
+	cache.Add("b", []byte("1234"))
+	if stats := cache.Stats(); stats.Bytes != 10 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", cache.Stats())
+	}
+
+	cache.Add("c", []byte("12"))
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("expected a to be evicted")
+	}
+
+	// Replacing an entry accounts for its new size.
+	cache.Add("c", []byte("1"))
+	if stats := cache.Stats(); stats.Bytes != 7 {
+		t.Errorf("expected 7 bytes, got %d", stats.Bytes)
+	}
+
+	// A value over the whole budget isn't cached.
+	cache.Add("big", make([]byte, 11))
+	if _, ok := cache.Get("big"); ok {
+		t.Errorf("expected oversized value to be skipped")
+	}
+	if stats := cache.Stats(); stats.Entries != 2 {
+		t.Errorf("expected existing entries to stay, got %+v", stats)
+	}
+}
+
+func TestAddWithTTL(t *testing.T) {
+	cache := NewCache(time.Hour)
+	defer cache.Close()
+	cache.AddWithTTL("list", []byte("page"), 5*time.Millisecond)
+	cache.Add("pokemon", []byte("pikachu"))
+
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := cache.Get("list"); ok {
+		t.Errorf("expected list to have expired")
+	}
+	if _, ok := cache.Get("pokemon"); !ok {
+		t.Errorf("expected to find pokemon")
+	}
+	if stats := cache.Stats(); stats.Expired != 1 || stats.Entries != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSlidingExpiration(t *testing.T) {
+	cache := NewCache(time.Hour, WithSlidingExpiration())
+	defer cache.Close()
+	cache.AddWithTTL("key", []byte("val"), 30*time.Millisecond)
+	for i := 0; i < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if _, ok := cache.Get("key"); !ok {
+			t.Fatalf("expected key to stay while in use")
+		}
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := cache.Get("key"); ok {
+		t.Errorf("expected key to expire once unused")
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	cache := NewCache(time.Hour)
+	defer cache.Close()
+	loads := 0
+	load := func() ([]byte, error) {
+		loads++
+		return []byte("loaded"), nil
+	}
+	for i := 0; i < 2; i++ {
+		val, err := cache.GetOrLoad("key", load)
+		if err != nil || string(val) != "loaded" {
+			t.Errorf("unexpected result %q, %v", val, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected 1 load, got %d", loads)
+	}
+
+	if _, err := cache.GetOrLoad("other", func() ([]byte, error) {
+		return nil, fmt.Errorf("failed")
+	}); err == nil {
+		t.Errorf("expected error from load")
+	}
+	if _, ok := cache.Get("other"); ok {
+		t.Errorf("expected failed load not to be cached")
+	}
+}
+
+func TestCloseStopsReaping(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cache := NewCache(5*time.Millisecond, WithContext(ctx))
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+
+	cache.Add("key", []byte("val"))
+	time.Sleep(20 * time.Millisecond)
+	if stats := cache.Stats(); stats.Expired != 0 || stats.Entries != 1 {
+		t.Errorf("expected no reaping after the context is done, got %+v", stats)
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Errorf("expected expired entry to be dropped on lookup")
+	}
+}
+
+func TestEntriesDeleteClear(t *testing.T) {
+	cache := NewCache(time.Hour)
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("22"))
+	cache.Get("a")
+
+	entries := cache.Entries()
+	if len(entries) != 2 || entries[0].Key != "a" || entries[1].Size != 3 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+	if val, _, ok := cache.Peek("b"); !ok || string(val) != "22" {
+		t.Errorf("expected to peek b")
+	}
+	if cache.Entries()[0].Key != "a" {
+		t.Errorf("expected Peek not to change the LRU order")
+	}
+
+	if !cache.Delete("a") || cache.Delete("a") {
+		t.Errorf("expected a to be deleted once")
+	}
+	cache.Clear()
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("expected an empty cache, got %+v", stats)
+	}
+}
+
+func TestDiskCacheEntries(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewDiskCache(dir)
+	for _, key := range []string{"https://example.com/a", "https://example.com/b"} {
+		if err := cache.Add(key, []byte("testdata")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// Files that aren't entries are left alone.
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("hi"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := cache.Entries()
+	if err != nil || len(entries) != 2 || entries[0].Size != int64(len("testdata")) {
+		t.Fatalf("unexpected entries %+v, %v", entries, err)
+	}
+	if err := cache.Delete("https://example.com/a"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, ok := cache.Get("https://example.com/a"); ok {
+		t.Errorf("expected a to be deleted")
+	}
+	if err := cache.Clear(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if entries, _ := cache.Entries(); len(entries) != 0 {
+		t.Errorf("expected no entries, got %+v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README")); err != nil {
+		t.Errorf("expected README to remain: %v", err)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	cache := NewCache(5*time.Second, WithCompression(64))
+	defer cache.Close()
+	large := []byte(strings.Repeat(`{"name":"pikachu","url":"https://pokeapi.co/api/v2/pokemon/25/"},`, 20))
+	small := []byte("small")
+	cache.Add("large", large)
+	cache.Add("small", small)
+
+	for key, expected := range map[string][]byte{"large": large, "small": small} {
+		val, ok := cache.Get(key)
+		if !ok || !bytes.Equal(val, expected) {
+			t.Errorf("%s: expected %q, got %q", key, expected, val)
+		}
+	}
+	codecs := make(map[string]string)
+	for _, entry := range cache.Entries() {
+		codecs[entry.Key] = entry.Codec
+	}
+	if codecs["large"] != "gzip" || codecs["small"] != "none" {
+		t.Errorf("expected large gzipped and small stored as is, got %v", codecs)
+	}
+	if val, _, ok := cache.Peek("large"); !ok || !bytes.Equal(val, large) {
+		t.Errorf("expected Peek to decompress, got %q", val)
+	}
+
+	stats := cache.Stats()
+	if stats.CompressedEntries != 1 || stats.Bytes >= stats.UncompressedBytes {
+		t.Errorf("expected one compressed entry saving space, got %+v", stats)
+	}
+	if expected := len("large") + len(large) + len("small") + len(small); stats.UncompressedBytes != expected {
+		t.Errorf("expected %d uncompressed bytes, got %d", expected, stats.UncompressedBytes)
+	}
+	cache.Delete("large")
+	if stats := cache.Stats(); stats.CompressedEntries != 0 || stats.Bytes != stats.UncompressedBytes {
+		t.Errorf("expected only the uncompressed entry to remain, got %+v", stats)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	cache := NewCache(5*time.Second, WithMaxBytes(40))
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("2"))
+
+	if !cache.Attach("a", []byte("1"), "int", 1, 10) {
+		t.Fatalf("expected to attach to a")
+	}
+	if v, ok := cache.Attached("a", "int"); !ok || v != 1 {
+		t.Errorf("expected 1 attached to a, got %v, %v", v, ok)
+	}
+	if _, ok := cache.Attached("a", "string"); ok {
+		t.Errorf("expected nothing attached to a under another tag")
+	}
+	// A value derived from what an entry held before isn't attached.
+	cache.Add("b", []byte("3"))
+	if cache.Attach("b", []byte("2"), "int", 2, 10) || cache.Attach("c", nil, "int", 0, 10) {
+		t.Errorf("expected not to attach to a replaced or missing entry")
+	}
+
+	// Attached values count against the budget: a, used last, stays.
+	if !cache.Attach("b", []byte("3"), "int", 3, 25) {
+		t.Fatalf("expected to attach to b")
+	}
+	cache.Attached("a", "int")
+	cache.Add("d", []byte("4"))
+	stats := cache.Stats()
+	if _, ok := cache.Get("b"); ok || stats.Attached != 1 || stats.AttachedBytes != 10 {
+		t.Errorf("expected b and its value evicted, got %+v", stats)
+	}
+
+	// Attached values go with their entry.
+	cache.Add("a", []byte("5"))
+	if _, ok := cache.Attached("a", "int"); ok {
+		t.Errorf("expected the value attached to a to go when a was replaced")
+	}
+	if stats := cache.Stats(); stats.Attached != 0 || stats.AttachedBytes != 0 {
+		t.Errorf("expected nothing attached, got %+v", stats)
+	}
+}
+
+// crawlResponses returns n responses shaped like those of a crawl of the
+// pokemon endpoint, which repeat long keys and sprite URLs.
+func crawlResponses(n int) map[string][]byte {
+	responses := make(map[string][]byte, n)
+	for id := 1; id <= n; id++ {
+		var body strings.Builder
+		fmt.Fprintf(&body, `{"id":%d,"name":"pokemon-%d","base_experience":%d,"moves":[`, id, id, id%300)
+		for move := 0; move < 60; move++ {
+			if move > 0 {
+				body.WriteString(",")
+			}
+			fmt.Fprintf(&body, `{"move":{"name":"move-%d","url":"https://pokeapi.co/api/v2/move/%d/"},`+
+				`"version_group_details":[{"level_learned_at":%d,"move_learn_method":{"name":"level-up",`+
+				`"url":"https://pokeapi.co/api/v2/move-learn-method/1/"}}]}`, (id*7+move)%900, (id*7+move)%900, move)
+		}
+		fmt.Fprintf(&body, `],"sprites":{"front_default":"https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/%d.png",`+
+			`"back_default":"https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/back/%d.png"}}`, id, id)
+		responses[fmt.Sprintf("https://pokeapi.co/api/v2/pokemon/%d/", id)] = []byte(body.String())
+	}
+	return responses
+}
+
+func BenchmarkCrawl(b *testing.B) {
+	responses := crawlResponses(1000)
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"uncompressed", nil},
+		{"gzip", []Option{WithCompression(4 << 10)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var stats Stats
+			for i := 0; i < b.N; i++ {
+				cache := NewCache(time.Minute, bench.opts...)
+				for key, val := range responses {
+					cache.Add(key, val)
+				}
+				stats = cache.Stats()
+				cache.Close()
+			}
+			b.ReportMetric(float64(stats.Bytes), "cache-bytes")
+			b.ReportMetric(float64(stats.UncompressedBytes)/float64(stats.Bytes), "ratio")
+		})
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	responses := crawlResponses(100)
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"uncompressed", nil},
+		{"gzip", []Option{WithCompression(4 << 10)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			cache := NewCache(time.Minute, bench.opts...)
+			defer cache.Close()
+			keys := make([]string, 0, len(responses))
+			for key, val := range responses {
+				cache.Add(key, val)
+				keys = append(keys, key)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cache.Get(keys[i%len(keys)])
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+// Package render lays out text for the terminal: aligned tables and
+// horizontal bars, colored with a terminal's escape codes when it has them.
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Renderer draws within a terminal's width and colors.
+type Renderer struct {
+	// Width is the number of columns output may take; zero means no
+	// limit.
+	Width int
+	// Colors are written around colored text; nil draws plain text.
+	Colors *term.EscapeCodes
+}
+
+// Color is one of the foreground colors of term.EscapeCodes.
+type Color int
+
+const (
+	Default Color = iota
+	Black
+	Red
+	Green
+	Yellow
+	Blue
+	Magenta
+	Cyan
+	White
+)
+
+func (r Renderer) escape(c Color) []byte {
+	if r.Colors == nil {
+		return nil
+	}
+	switch c {
+	case Black:
+		return r.Colors.Black
+	case Red:
+		return r.Colors.Red
+	case Green:
+		return r.Colors.Green
+	case Yellow:
+		return r.Colors.Yellow
+	case Blue:
+		return r.Colors.Blue
+	case Magenta:
+		return r.Colors.Magenta
+	case Cyan:
+		return r.Colors.Cyan
+	case White:
+		return r.Colors.White
+	}
+	return nil
+}
+
+// Paint returns s in color c, or s itself when drawing plain text.
+func (r Renderer) Paint(c Color, s string) string {
+	escape := r.escape(c)
+	if escape == nil || s == "" {
+		return s
+	}
+	return string(escape) + s + string(r.Colors.Reset)
+}
+
+// typeColors are the nearest terminal colors to the colors of each type
+// in the games.
+var typeColors = map[string]Color{
+	"normal":   White,
+	"fire":     Red,
+	"water":    Blue,
+	"electric": Yellow,
+	"grass":    Green,
+	"ice":      Cyan,
+	"fighting": Red,
+	"poison":   Magenta,
+	"ground":   Yellow,
+	"flying":   Cyan,
+	"psychic":  Magenta,
+	"bug":      Green,
+	"rock":     Yellow,
+	"ghost":    Magenta,
+	"dragon":   Blue,
+	"dark":     Black,
+	"steel":    White,
+	"fairy":    Magenta,
+}
+
+// TypeColor returns the color a Pokemon type is drawn in, e.g. Red for
+// "fire".
+func TypeColor(typ string) Color {
+	return typeColors[typ]
+}
+
+// Table is a grid of cells drawn in aligned columns. Cells may already be
+// colored with Paint.
+type Table struct {
+	// Header, if set, is drawn above the rows and underlined.
+	Header []string
+	Rows   [][]string
+	// RightAlign marks the columns to align right, such as numbers.
+	RightAlign []bool
+}
+
+// columnGap separates table columns.
+const columnGap = "  "
+
+// Table writes t to w. If it is wider than the renderer, the widest
+// columns are shortened, cutting their cells off with "…".
+func (r Renderer) Table(w io.Writer, t Table) {
+	rows := t.Rows
+	if t.Header != nil {
+		rows = append([][]string{t.Header}, rows...)
+	}
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], Width(cell))
+		}
+	}
+	r.fit(widths)
+
+	for i, row := range rows {
+		var line strings.Builder
+		for j, cell := range row {
+			if j > 0 {
+				line.WriteString(columnGap)
+			}
+			cell = Truncate(cell, widths[j])
+			pad := strings.Repeat(" ", widths[j]-Width(cell))
+			if j < len(t.RightAlign) && t.RightAlign[j] {
+				line.WriteString(pad + cell)
+			} else if j < len(row)-1 {
+				line.WriteString(cell + pad)
+			} else {
+				line.WriteString(cell)
+			}
+		}
+		fmt.Fprintln(w, line.String())
+		if i == 0 && t.Header != nil {
+			var rule []string
+			for _, width := range widths {
+				rule = append(rule, strings.Repeat("-", width))
+			}
+			fmt.Fprintln(w, strings.Join(rule, columnGap))
+		}
+	}
+}
+
+// fit shrinks the widest of widths until the columns fit in the renderer's
+// width, keeping each at least a few columns wide.
+func (r Renderer) fit(widths []int) {
+	const minWidth = 4
+	if r.Width <= 0 {
+		return
+	}
+	total := func() int {
+		sum := len(columnGap) * max(len(widths)-1, 0)
+		for _, width := range widths {
+			sum += width
+		}
+		return sum
+	}
+	for total() > r.Width {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minWidth {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// Bar returns a bar width columns long, filled in proportion to value out
+// of full and drawn in color c.
+func (r Renderer) Bar(value, full, width int, c Color) string {
+	filled := 0
+	if full > 0 {
+		filled = min(value*width/full, width)
+	}
+	if value > 0 && filled == 0 {
+		filled = 1
+	}
+	if r.Colors == nil {
+		return strings.Repeat("#", filled) + strings.Repeat(".", width-filled)
+	}
+	return r.Paint(c, strings.Repeat("█", filled)) + strings.Repeat("░", width-filled)
+}
+
+// Stat is a named value drawn by Stats.
+type Stat struct {
+	Name  string
+	Value int
+}
+
+// maxBarWidth keeps bars readable on wide terminals.
+const maxBarWidth = 40
+
+// Stats writes a row per stat with its value and a bar scaled to full,
+// followed by their total, indented by indent. The bars take the width
+// left over, if there is room for them.
+func (r Renderer) Stats(w io.Writer, indent string, stats []Stat, full int, c Color) {
+	nameWidth, total := len("total"), 0
+	for _, stat := range stats {
+		nameWidth = max(nameWidth, Width(stat.Name))
+		total += stat.Value
+	}
+	valueWidth := len(fmt.Sprint(total))
+	barWidth := maxBarWidth
+	if r.Width > 0 {
+		barWidth = min(barWidth, r.Width-len(indent)-nameWidth-valueWidth-2*len(columnGap))
+	}
+
+	for _, stat := range stats {
+		line := fmt.Sprintf("%s%-*s%s%*d", indent, nameWidth, stat.Name, columnGap, valueWidth, stat.Value)
+		if barWidth >= 10 {
+			line += columnGap + r.Bar(stat.Value, full, barWidth, c)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%s%-*s%s%*d\n", indent, nameWidth, "total", columnGap, valueWidth, total)
+}
+
+// Width returns the number of columns s takes, ignoring escape sequences.
+func Width(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if size := escapeLen(s[i:]); size > 0 {
+			i += size
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// Truncate cuts s down to width columns, ending it with "…" if anything was
+// cut. Escape sequences are kept, with colors reset after the cut.
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	n, escaped := 0, false
+	for i := 0; i < len(s); {
+		if size := escapeLen(s[i:]); size > 0 {
+			b.WriteString(s[i : i+size])
+			i += size
+			escaped = true
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		if n == width-1 {
+			break
+		}
+		b.WriteString(s[i : i+size])
+		i += size
+		n++
+	}
+	b.WriteString("…")
+	if escaped {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// escapeLen returns the length of the CSI escape sequence s starts with,
+// or 0.
+func escapeLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if c := s[i]; c >= 0x40 && c <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/term"
+)
+
+func TestTable(t *testing.T) {
+	var out strings.Builder
+	Renderer{}.Table(&out, Table{
+		Header:     []string{"stat", "value"},
+		Rows:       [][]string{{"hp", "35"}, {"special-attack", "50"}},
+		RightAlign: []bool{false, true},
+	})
+	expected := "" +
+		"stat            value\n" +
+		"--------------  -----\n" +
+		"hp                 35\n" +
+		"special-attack     50\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestTableFitsWidth(t *testing.T) {
+	var out strings.Builder
+	r := Renderer{Width: 16}
+	r.Table(&out, Table{Rows: [][]string{
+		{"flavor", "When several of these Pokemon gather, their electricity could build."},
+	}})
+	expected := "flavor  When se…\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestWidthAndTruncate(t *testing.T) {
+	r := Renderer{Colors: &term.EscapeCodes{Red: []byte("\x1b[31m"), Reset: []byte("\x1b[0m")}}
+	painted := r.Paint(Red, "fire")
+	if painted != "\x1b[31mfire\x1b[0m" || Width(painted) != 4 {
+		t.Errorf("unexpected painted text %q of width %d", painted, Width(painted))
+	}
+	if actual := Truncate(painted, 3); actual != "\x1b[31mfi…\x1b[0m" {
+		t.Errorf("unexpected truncation %q", actual)
+	}
+	if actual := (Renderer{}).Paint(Red, "fire"); actual != "fire" {
+		t.Errorf("expected plain text without colors, got %q", actual)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var out strings.Builder
+	Renderer{Width: 24}.Stats(&out, "  ", []Stat{{"hp", 255}, {"speed", 51}}, 255, Yellow)
+	expected := "" +
+		"  hp     255  ##########\n" +
+		"  speed   51  ##........\n" +
+		"  total  306\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+
+	// Without room for bars, only the values are shown.
+	out.Reset()
+	Renderer{Width: 20}.Stats(&out, "", []Stat{{"hp", 45}}, 255, Yellow)
+	if expected := "hp     45\ntotal  45\n"; out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
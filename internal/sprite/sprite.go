@@ -0,0 +1,194 @@
+// Package sprite draws small images, such as Pokemon sprites, as text in a
+// terminal. Each character cell shows two pixels stacked vertically using
+// the upper half block "▀", with the top pixel as the foreground color and
+// the bottom one as the background.
+package sprite
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // showdown sprites are animated GIFs
+	_ "image/png"
+	"strings"
+)
+
+// Mode is how colors are drawn.
+type Mode int
+
+const (
+	// ASCII draws shades of gray as characters, without escape sequences.
+	ASCII Mode = iota
+	// Color256 uses the xterm 256-color palette.
+	Color256
+	// TrueColor uses 24-bit colors.
+	TrueColor
+)
+
+// DetectMode picks the best Mode the terminal described by the environment
+// supports, given a function such as os.Getenv. NO_COLOR disables colors.
+func DetectMode(getenv func(string) string) Mode {
+	if getenv("NO_COLOR") != "" {
+		return ASCII
+	}
+	switch colorterm := getenv("COLORTERM"); colorterm {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	switch term := getenv("TERM"); {
+	case term == "" || term == "dumb":
+		return ASCII
+	case strings.Contains(term, "direct"):
+		return TrueColor
+	}
+	return Color256
+}
+
+// Decode decodes a PNG or GIF image, taking the first frame of an animated
+// GIF.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// asciiRamp holds characters from lightest to darkest.
+const asciiRamp = " .:-=+*#%@"
+
+// Render draws img cropped to its opaque pixels and scaled down to at most
+// maxWidth columns, one line per two rows of pixels. Every line ends with a
+// newline.
+func Render(img image.Image, maxWidth int, mode Mode) string {
+	bounds := opaqueBounds(img)
+	if bounds.Empty() {
+		return ""
+	}
+	scale := 1
+	if maxWidth > 0 && bounds.Dx() > maxWidth {
+		scale = (bounds.Dx() + maxWidth - 1) / maxWidth
+	}
+	width := bounds.Dx() / scale
+	height := bounds.Dy() / scale
+	at := func(x, y int) (color.NRGBA, bool) {
+		if y >= height {
+			return color.NRGBA{}, false
+		}
+		c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x*scale, bounds.Min.Y+y*scale)).(color.NRGBA)
+		return c, c.A >= 128
+	}
+
+	var b strings.Builder
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x++ {
+			top, topOK := at(x, y)
+			bottom, bottomOK := at(x, y+1)
+			if mode == ASCII {
+				b.WriteByte(shade(top, topOK, bottom, bottomOK))
+				continue
+			}
+			switch {
+			case topOK && bottomOK:
+				fmt.Fprintf(&b, "%s%s▀", escape(38, top, mode), escape(48, bottom, mode))
+			case topOK:
+				fmt.Fprintf(&b, "\x1b[49m%s▀", escape(38, top, mode))
+			case bottomOK:
+				fmt.Fprintf(&b, "\x1b[49m%s▄", escape(38, bottom, mode))
+			default:
+				b.WriteString("\x1b[0m ")
+			}
+		}
+		if mode != ASCII {
+			b.WriteString("\x1b[0m")
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// opaqueBounds returns the smallest rectangle holding every opaque pixel
+// of img.
+func opaqueBounds(img image.Image) image.Rectangle {
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a >= 0x8000 {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+// escape returns the SGR sequence setting the foreground (38) or
+// background (48) color to c.
+func escape(layer int, c color.NRGBA, mode Mode) string {
+	if mode == TrueColor {
+		return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("\x1b[%d;5;%dm", layer, palette256(c))
+}
+
+// cubeLevels are the intensities of the 6x6x6 color cube in the xterm
+// 256-color palette.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// palette256 returns the xterm 256-color palette index closest to c, from
+// the color cube or the grayscale ramp.
+func palette256(c color.NRGBA) int {
+	nearest := func(v int) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if abs(level-v) < abs(cubeLevels[best]-v) {
+				best = i
+			}
+		}
+		return best
+	}
+	r, g, b := int(c.R), int(c.G), int(c.B)
+	ri, gi, bi := nearest(r), nearest(g), nearest(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := sq(cubeLevels[ri]-r) + sq(cubeLevels[gi]-g) + sq(cubeLevels[bi]-b)
+
+	// The grayscale ramp runs from 8 to 238 in steps of 10.
+	gray := (r + g + b) / 3
+	grayIndex := min(max((gray-8+5)/10, 0), 23)
+	level := 8 + 10*grayIndex
+	grayDist := sq(level-r) + sq(level-g) + sq(level-b)
+	if grayDist < cubeDist {
+		return 232 + grayIndex
+	}
+	return cube
+}
+
+// shade returns the character of asciiRamp for the average luminance of the
+// opaque pixels among top and bottom, treating dark pixels as dense.
+func shade(top color.NRGBA, topOK bool, bottom color.NRGBA, bottomOK bool) byte {
+	var sum, n int
+	for _, p := range []struct {
+		c  color.NRGBA
+		ok bool
+	}{{top, topOK}, {bottom, bottomOK}} {
+		if p.ok {
+			sum += (299*int(p.c.R) + 587*int(p.c.G) + 114*int(p.c.B)) / 1000
+			n++
+		}
+	}
+	if n == 0 {
+		return ' '
+	}
+	luminance := sum / n
+	// Skip the space so opaque pixels stay visible, even white ones.
+	return asciiRamp[1+(255-luminance)*(len(asciiRamp)-2)/255]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sq(n int) int {
+	return n * n
+}
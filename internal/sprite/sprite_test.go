@@ -0,0 +1,81 @@
+package sprite
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// testImage is 4x4 with a transparent border around a 2x2 square of red
+// over blue.
+func testImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	img.Set(1, 1, red)
+	img.Set(2, 1, red)
+	img.Set(1, 2, blue)
+	img.Set(2, 2, blue)
+	return img
+}
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		mode     Mode
+		expected string
+	}{
+		{mode: TrueColor, expected: strings.Repeat("\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀", 2) + "\x1b[0m\n"},
+		{mode: Color256, expected: strings.Repeat("\x1b[38;5;196m\x1b[48;5;21m▀", 2) + "\x1b[0m\n"},
+		{mode: ASCII, expected: "##\n"},
+	}
+	for _, c := range cases {
+		actual := Render(testImage(), 0, c.mode)
+		if actual != c.expected {
+			t.Errorf("mode %d: expected %q, got %q", c.mode, c.expected, actual)
+		}
+	}
+}
+
+func TestRenderTransparent(t *testing.T) {
+	img := testImage()
+	img.Set(2, 2, color.NRGBA{})
+	expected := "\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀\x1b[49m\x1b[38;2;255;0;0m▀\x1b[0m\n"
+	if actual := Render(img, 0, TrueColor); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual := Render(image.NewNRGBA(image.Rect(0, 0, 4, 4)), 0, TrueColor); actual != "" {
+		t.Errorf("expected nothing for a transparent image, got %q", actual)
+	}
+}
+
+func TestRenderScales(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 96, 96))
+	for y := 0; y < 96; y++ {
+		for x := 0; x < 96; x++ {
+			img.Set(x, y, color.NRGBA{G: 255, A: 255})
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(Render(img, 40, ASCII), "\n"), "\n")
+	if len(lines) != 16 || len(lines[0]) != 32 {
+		t.Errorf("expected 16 lines of 32 columns, got %d of %d", len(lines), len(lines[0]))
+	}
+}
+
+func TestDetectMode(t *testing.T) {
+	cases := []struct {
+		env      map[string]string
+		expected Mode
+	}{
+		{env: map[string]string{"COLORTERM": "truecolor", "TERM": "xterm-256color"}, expected: TrueColor},
+		{env: map[string]string{"TERM": "xterm-256color"}, expected: Color256},
+		{env: map[string]string{"TERM": "dumb"}, expected: ASCII},
+		{env: map[string]string{"COLORTERM": "truecolor", "NO_COLOR": "1"}, expected: ASCII},
+	}
+	for _, c := range cases {
+		actual := DetectMode(func(key string) string { return c.env[key] })
+		if actual != c.expected {
+			t.Errorf("DetectMode(%v): expected %d, got %d", c.env, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// ctrlC is the byte a raw-mode terminal sends for Ctrl-C.
+const ctrlC = 0x03
+
+// keyboard reads stdin in the background so that Ctrl-C can be noticed
+// while a command runs, when the terminal isn't reading. Everything else
+// is buffered for the terminal's next ReadLine.
+type keyboard struct {
+	mux   sync.Mutex
+	ready *sync.Cond
+	buf   []byte
+	err   error
+	// interrupt cancels the running command, if any.
+	interrupt context.CancelFunc
+}
+
+func newKeyboard(r io.Reader) *keyboard {
+	k := &keyboard{}
+	k.ready = sync.NewCond(&k.mux)
+	go k.run(r)
+	return k
+}
+
+func (k *keyboard) run(r io.Reader) {
+	data := make([]byte, 256)
+	for {
+		n, err := r.Read(data)
+		k.mux.Lock()
+		if k.interrupt != nil && bytes.IndexByte(data[:n], ctrlC) >= 0 {
+			k.interrupt()
+		} else {
+			k.buf = append(k.buf, data[:n]...)
+		}
+		k.err = err
+		k.ready.Broadcast()
+		k.mux.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (k *keyboard) Read(p []byte) (int, error) {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	for len(k.buf) == 0 && k.err == nil {
+		k.ready.Wait()
+	}
+	if len(k.buf) == 0 {
+		return 0, k.err
+	}
+	n := copy(p, k.buf)
+	k.buf = k.buf[n:]
+	return n, nil
+}
+
+// watch returns a context that Ctrl-C cancels until stop is called.
+func (k *keyboard) watch(ctx context.Context) (watched context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	k.mux.Lock()
+	k.interrupt = cancel
+	k.mux.Unlock()
+	return ctx, func() {
+		k.mux.Lock()
+		k.interrupt = nil
+		k.mux.Unlock()
+		cancel()
+	}
+}
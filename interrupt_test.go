@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestKeyboard(t *testing.T) {
+	r, w := io.Pipe()
+	k := newKeyboard(r)
+
+	ctx, stop := k.watch(context.Background())
+	w.Write([]byte("ab"))
+	w.Write([]byte{ctrlC})
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected Ctrl-C to cancel the command")
+	}
+	stop()
+
+	// Keys typed while a command runs are kept; Ctrl-C is only swallowed
+	// then.
+	w.Write([]byte{'c', ctrlC})
+	w.Close()
+	data, err := io.ReadAll(k)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "abc\x03"; string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, data)
+	}
+}
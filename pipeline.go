@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+const defaultHeadCount = 10
+
+// record is one item of structured command output that can be piped into
+// the next command. Every record has a name; fields hold any further
+// attributes, several values for multi-valued ones such as types.
+type record struct {
+	name   string
+	fields map[string][]string
+}
+
+func newRecord(name string) record {
+	return record{name: name, fields: make(map[string][]string)}
+}
+
+func (r record) set(field string, values ...string) record {
+	r.fields[field] = values
+	return r
+}
+
+func (r record) values(field string) []string {
+	if field == "name" {
+		return []string{r.name}
+	}
+	return r.fields[field]
+}
+
+// pokemonRecord describes a Pokemon for filtering and sorting: its ID,
+// types, base stats and their total ("bst").
+func pokemonRecord(pokemon pokeapi.Pokemon) record {
+	r := newRecord(pokemon.Name).
+		set("id", strconv.Itoa(pokemon.ID)).
+		set("height", strconv.Itoa(pokemon.Height)).
+		set("weight", strconv.Itoa(pokemon.Weight)).
+		set("base_experience", strconv.Itoa(pokemon.BaseExperience))
+	r.set("type", pokemon.TypeNames()...)
+	var abilities []string
+	for _, a := range pokemon.Abilities {
+		abilities = append(abilities, a.Ability.Name)
+	}
+	r.set("ability", abilities...)
+	for _, stat := range pokemon.Stats {
+		r.set(stat.Stat.Name, strconv.Itoa(stat.BaseStat))
+	}
+	return r.set("bst", strconv.Itoa(pokemon.BaseStatTotal()))
+}
+
+// pipeline is a list of commands connected by "|"; each stage is the words
+// of one command.
+type pipeline [][]string
+
+// chainLink is a pipeline together with the operator joining it to the
+// previous one: "" for the first, ";" or "&&".
+type chainLink struct {
+	op       string
+	pipeline pipeline
+}
+
+// parseChain groups tokens into pipelines joined by ";" and "&&".
+func parseChain(tokens []token) ([]chainLink, error) {
+	var links []chainLink
+	current := chainLink{}
+	var stage []string
+
+	endStage := func(op string) error {
+		if len(stage) == 0 {
+			return fmt.Errorf("syntax error near %s", op)
+		}
+		current.pipeline = append(current.pipeline, stage)
+		stage = nil
+		return nil
+	}
+
+	for _, t := range tokens {
+		if !t.op {
+			stage = append(stage, t.text)
+			continue
+		}
+		if err := endStage(t.text); err != nil {
+			return nil, err
+		}
+		if t.text != opPipe {
+			links = append(links, current)
+			current = chainLink{op: t.text}
+		}
+	}
+
+	if len(stage) > 0 {
+		current.pipeline = append(current.pipeline, stage)
+		return append(links, current), nil
+	}
+	// Only a trailing ";" may end the line without a command after it.
+	if len(current.pipeline) > 0 || current.op == opAnd {
+		return nil, fmt.Errorf("syntax error: missing command at end of line")
+	}
+	return links, nil
+}
+
+// recordFilter reports whether a record matches a filter expression.
+type recordFilter func(record) bool
+
+// parseFilter parses expressions such as "type=water", "bst>500" or
+// "name~chu" and returns a filter matching records that satisfy all of
+// them. Multi-valued fields match if any value does, except for "!=" which
+// requires that none does. Values compare numerically when both sides are
+// numbers.
+func parseFilter(exprs []string) (recordFilter, error) {
+	var filters []recordFilter
+	for _, expr := range exprs {
+		i := strings.IndexAny(expr, "=!<>~")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid filter %q, expected field<op>value with op one of = != < <= > >= ~", expr)
+		}
+		field := strings.ToLower(expr[:i])
+		op := expr[i : i+1]
+		if i+1 < len(expr) && expr[i+1] == '=' && op != "=" && op != "~" {
+			op += "="
+		}
+		if op == "!" {
+			return nil, fmt.Errorf("invalid filter %q, did you mean !=", expr)
+		}
+		want := strings.ToLower(expr[i+len(op):])
+
+		filters = append(filters, func(r record) bool {
+			values := r.values(field)
+			if op == "!=" {
+				for _, value := range values {
+					if compareValues(value, want) == 0 {
+						return false
+					}
+				}
+				return true
+			}
+			for _, value := range values {
+				if matchValue(value, op, want) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	return func(r record) bool {
+		for _, filter := range filters {
+			if !filter(r) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func matchValue(value, op, want string) bool {
+	if op == "~" {
+		return strings.Contains(strings.ToLower(value), want)
+	}
+	c := compareValues(value, want)
+	switch op {
+	case "=":
+		return c == 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// compareValues compares a and b numerically if both are numbers and
+// case-insensitively otherwise.
+func compareValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// sortRecords sorts records by the first value of field, keeping the
+// original order among equal records.
+func sortRecords(records []record, field string, reverse bool) {
+	first := func(r record) string {
+		if values := r.values(field); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		c := compareValues(first(records[i]), first(records[j]))
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func printRecords(out io.Writer, records []record) {
+	for _, r := range records {
+		fmt.Fprintln(out, r.name)
+	}
+}
+
+func requirePipe(in *invocation) error {
+	if !in.piped {
+		return fmt.Errorf("%s reads the output of another command, e.g. pokedex | %s", in.command.name, in.command.name)
+	}
+	return nil
+}
+
+func commandFilter(s *session, in *invocation) error {
+	if err := requirePipe(in); err != nil {
+		return err
+	}
+	filter, err := parseFilter(in.list("conditions"))
+	if err != nil {
+		return err
+	}
+	for _, r := range in.input {
+		if filter(r) {
+			in.emit(r)
+		}
+	}
+	printRecords(in.out, in.records)
+	return nil
+}
+
+func commandSort(s *session, in *invocation) error {
+	if err := requirePipe(in); err != nil {
+		return err
+	}
+	field := "name"
+	if in.has("field") {
+		field = strings.ToLower(in.str("field"))
+	}
+	records := append([]record(nil), in.input...)
+	sortRecords(records, field, in.bool("reverse"))
+	for _, r := range records {
+		in.emit(r)
+	}
+	printRecords(in.out, in.records)
+	return nil
+}
+
+func commandHead(s *session, in *invocation) error {
+	if err := requirePipe(in); err != nil {
+		return err
+	}
+	n := defaultHeadCount
+	if in.has("count") {
+		n = in.int("count")
+	}
+	for i := 0; i < len(in.input) && i < n; i++ {
+		in.emit(in.input[i])
+	}
+	printRecords(in.out, in.records)
+	return nil
+}
+
+func commandCount(s *session, in *invocation) error {
+	if err := requirePipe(in); err != nil {
+		return err
+	}
+	count := strconv.Itoa(len(in.input))
+	in.emit(newRecord(count))
+	fmt.Fprintln(in.out, count)
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/term"
+)
+
+func TestParseChain(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []chainLink
+	}{
+		{
+			input: "explore a | catch",
+			expected: []chainLink{
+				{pipeline: pipeline{{"explore", "a"}, {"catch"}}},
+			},
+		},
+		{
+			input: "map; mapb && pokedex|count;",
+			expected: []chainLink{
+				{pipeline: pipeline{{"map"}}},
+				{op: ";", pipeline: pipeline{{"mapb"}}},
+				{op: "&&", pipeline: pipeline{{"pokedex"}, {"count"}}},
+			},
+		},
+		{
+			input:    `filter "a|b"`,
+			expected: []chainLink{{pipeline: pipeline{{"filter", "a|b"}}}},
+		},
+	}
+
+	for _, c := range cases {
+		tokens, err := lex(c.input)
+		if err != nil {
+			t.Fatalf("lex(%q): unexpected error: %v", c.input, err)
+		}
+		actual, err := parseChain(tokens)
+		if err != nil {
+			t.Errorf("parseChain(%q): unexpected error: %v", c.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("parseChain(%q): expected %v, got %v", c.input, c.expected, actual)
+		}
+	}
+
+	for _, input := range []string{"| count", "map |", "map && ", "map ;; mapb", "map || mapb"} {
+		tokens, err := lex(input)
+		if err == nil {
+			_, err = parseChain(tokens)
+		}
+		if err == nil {
+			t.Errorf("%q: expected syntax error", input)
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	r := newRecord("pikachu").set("type", "electric").set("bst", "320")
+	dual := newRecord("gyarados").set("type", "water", "flying").set("bst", "540")
+
+	cases := []struct {
+		exprs    []string
+		expected []bool
+	}{
+		{exprs: []string{"type=water"}, expected: []bool{false, true}},
+		{exprs: []string{"type!=flying"}, expected: []bool{true, false}},
+		{exprs: []string{"bst>=500"}, expected: []bool{false, true}},
+		{exprs: []string{"bst<500", "name~CHU"}, expected: []bool{true, false}},
+		{exprs: []string{"missing=1"}, expected: []bool{false, false}},
+	}
+	for _, c := range cases {
+		filter, err := parseFilter(c.exprs)
+		if err != nil {
+			t.Fatalf("parseFilter(%v): unexpected error: %v", c.exprs, err)
+		}
+		actual := []bool{filter(r), filter(dual)}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("parseFilter(%v): expected %v, got %v", c.exprs, c.expected, actual)
+		}
+	}
+
+	for _, expr := range []string{"water", "=water", "type!water"} {
+		if _, err := parseFilter([]string{expr}); err == nil {
+			t.Errorf("parseFilter(%q): expected error", expr)
+		}
+	}
+}
+
+func TestSortRecords(t *testing.T) {
+	records := []record{
+		newRecord("b").set("bst", "100"),
+		newRecord("a").set("bst", "20"),
+		newRecord("c").set("bst", "3"),
+	}
+	sortRecords(records, "bst", false)
+	if names := recordNames(records); !reflect.DeepEqual(names, []string{"c", "a", "b"}) {
+		t.Errorf("expected numeric order, got %v", names)
+	}
+	sortRecords(records, "name", true)
+	if names := recordNames(records); !reflect.DeepEqual(names, []string{"c", "b", "a"}) {
+		t.Errorf("expected reverse name order, got %v", names)
+	}
+}
+
+func recordNames(records []record) []string {
+	names := make([]string, len(records))
+	for i, r := range records {
+		names[i] = r.name
+	}
+	return names
+}
+
+func TestExecutePipes(t *testing.T) {
+	var out bytes.Buffer
+	var caught []string
+	registry := newRegistry(
+		&command{name: "explore", handler: func(s *session, in *invocation) error {
+			for _, name := range []string{"pidgey", "pikachu", "rattata"} {
+				in.emit(newRecord(name).set("type", "normal"))
+			}
+			return nil
+		}},
+		&command{
+			name: "catch",
+			args: []argSpec{{name: "pokemon", pipe: true}},
+			handler: func(s *session, in *invocation) error {
+				caught = append(caught, in.str("pokemon"))
+				return nil
+			},
+		},
+		&command{name: "fail", handler: func(s *session, in *invocation) error {
+			return errors.New("failed")
+		}},
+		&command{name: "filter", args: []argSpec{{name: "conditions", variadic: true}}, handler: commandFilter},
+		&command{name: "head", args: []argSpec{{name: "count", typ: valueInt, optional: true}}, handler: commandHead},
+		&command{name: "count", handler: commandCount},
+	)
+	s := &session{
+		ctx:      context.Background(),
+		terminal: term.NewTerminal(&screen{strings.NewReader(""), &out}, ""),
+		registry: registry,
+	}
+	s.config, _ = loadConfig("")
+
+	if err := s.execute("explore | filter name~pi | head 1 | catch"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(caught, []string{"pidgey"}) {
+		t.Errorf("expected to catch pidgey, got %v", caught)
+	}
+
+	out.Reset()
+	if err := s.execute("explore | count"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "3" {
+		t.Errorf("expected count 3, got %q", out.String())
+	}
+
+	caught = nil
+	if err := s.execute("fail && catch a; catch b"); err != nil {
+		t.Errorf("expected the status of the last command, got %v", err)
+	}
+	if !reflect.DeepEqual(caught, []string{"b"}) {
+		t.Errorf("expected && to skip catch a, got %v", caught)
+	}
+	if err := s.execute("catch c && fail"); err == nil {
+		t.Errorf("expected the last command's error")
+	}
+
+	if err := s.execute("count"); err == nil {
+		t.Errorf("expected error for count without piped input")
+	}
+}
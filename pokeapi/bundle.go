@@ -0,0 +1,276 @@
+package pokeapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const bundleIndexName = "index.json"
+
+// ErrNotInBundle is returned, wrapped, for data an offline bundle lacks,
+// including sprites, which bundles never hold.
+var ErrNotInBundle = errors.New("not in the offline bundle")
+
+// Bundle is an offline copy of PokeAPI resources in a zip archive, as
+// written by BuildBundle. It serves API requests as an http.RoundTripper,
+// so a client using it with WithBundle never touches the network.
+//
+// The archive holds, for each kind, the full list as <kind>/index.json and
+// each resource as <kind>/<id>.json.
+type Bundle struct {
+	zr    *zip.ReadCloser
+	files map[string]*zip.File
+
+	indexes  map[ResourceKind]NamedAPIResourceList
+	indexMux sync.Mutex
+}
+
+// OpenBundle opens a bundle written by BuildBundle.
+func OpenBundle(name string) (*Bundle, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	b := &Bundle{
+		zr:      zr,
+		files:   make(map[string]*zip.File),
+		indexes: make(map[ResourceKind]NamedAPIResourceList),
+	}
+	for _, f := range zr.File {
+		b.files[f.Name] = f
+	}
+	return b, nil
+}
+
+func (b *Bundle) Close() error {
+	return b.zr.Close()
+}
+
+// Kinds returns the kinds of resources in the bundle.
+func (b *Bundle) Kinds() []ResourceKind {
+	var kinds []ResourceKind
+	for name := range b.files {
+		if dir, file := path.Split(name); file == bundleIndexName {
+			kinds = append(kinds, ResourceKind(strings.TrimSuffix(dir, "/")))
+		}
+	}
+	return kinds
+}
+
+// WithBundle serves every request from b instead of the network.
+func WithBundle(b *Bundle) Option {
+	return func(p *PokeAPIWrapper) {
+		p.HTTPClient = &http.Client{Transport: b}
+		p.offline = true
+	}
+}
+
+// RoundTrip answers a GET of a list endpoint or a resource from the
+// bundle. Unknown names of a kind the bundle has get a 404, like from the
+// API; requests for kinds or resources the bundle lacks fail with an error
+// saying so.
+func (b *Bundle) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	last := segments[len(segments)-1]
+
+	if len(segments) >= 2 && b.has(ResourceKind(segments[len(segments)-2])) {
+		return b.resource(req, ResourceKind(segments[len(segments)-2]), last)
+	}
+	if b.has(ResourceKind(last)) {
+		return b.list(req, ResourceKind(last))
+	}
+	return nil, fmt.Errorf("no data for %s: %w", req.URL.Path, ErrNotInBundle)
+}
+
+func (b *Bundle) has(kind ResourceKind) bool {
+	_, ok := b.files[path.Join(string(kind), bundleIndexName)]
+	return ok
+}
+
+func (b *Bundle) index(kind ResourceKind) (NamedAPIResourceList, error) {
+	b.indexMux.Lock()
+	defer b.indexMux.Unlock()
+	if list, ok := b.indexes[kind]; ok {
+		return list, nil
+	}
+
+	data, err := b.read(path.Join(string(kind), bundleIndexName))
+	if err != nil {
+		return NamedAPIResourceList{}, err
+	}
+	var list NamedAPIResourceList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return NamedAPIResourceList{}, fmt.Errorf("corrupt %s index in bundle: %w", kind, err)
+	}
+	b.indexes[kind] = list
+	return list, nil
+}
+
+func (b *Bundle) read(name string) ([]byte, error) {
+	f, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is %w", name, ErrNotInBundle)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func (b *Bundle) resource(req *http.Request, kind ResourceKind, nameOrID string) (*http.Response, error) {
+	list, err := b.index(kind)
+	if err != nil {
+		return nil, err
+	}
+	id := 0
+	for _, r := range list.Results {
+		if r.Name == nameOrID || strconv.Itoa(r.ID()) == nameOrID {
+			id = r.ID()
+			break
+		}
+	}
+	if id == 0 {
+		return bundleResponse(req, http.StatusNotFound, []byte("Not Found")), nil
+	}
+
+	data, err := b.read(path.Join(string(kind), strconv.Itoa(id)+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s is %w", kind, nameOrID, ErrNotInBundle)
+	}
+	return bundleResponse(req, http.StatusOK, data), nil
+}
+
+// list synthesizes a page of a list endpoint from the full list.
+func (b *Bundle) list(req *http.Request, kind ResourceKind) (*http.Response, error) {
+	list, err := b.index(kind)
+	if err != nil {
+		return nil, err
+	}
+	page := FirstPage()
+	query := req.URL.Query()
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil && offset >= 0 {
+		page.Offset = offset
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+		page.Limit = limit
+	}
+
+	pageURL := func(page Page) *string {
+		u := *req.URL
+		q := u.Query()
+		q.Set("offset", strconv.Itoa(page.Offset))
+		q.Set("limit", strconv.Itoa(page.limit()))
+		u.RawQuery = q.Encode()
+		s := u.String()
+		return &s
+	}
+	result := struct {
+		Count    int                `json:"count"`
+		Next     *string            `json:"next"`
+		Previous *string            `json:"previous"`
+		Results  []NamedAPIResource `json:"results"`
+	}{
+		Count:   len(list.Results),
+		Results: []NamedAPIResource{},
+	}
+	start := min(page.Offset, len(list.Results))
+	end := min(page.Offset+page.limit(), len(list.Results))
+	result.Results = append(result.Results, list.Results[start:end]...)
+	if end < len(list.Results) {
+		result.Next = pageURL(page.Next())
+	}
+	if page.Offset > 0 {
+		result.Previous = pageURL(page.Previous())
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return bundleResponse(req, http.StatusOK, data), nil
+}
+
+func bundleResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// bundleEntry is a resource kept verbatim, along with its ID.
+type bundleEntry struct {
+	ID   int
+	data []byte
+}
+
+func (e *bundleEntry) UnmarshalJSON(data []byte) error {
+	var resource struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return err
+	}
+	e.ID = resource.ID
+	e.data = append([]byte(nil), data...)
+	return nil
+}
+
+// BuildBundle crawls every resource of the given kinds and writes them to w
+// as a bundle for OpenBundle. Resources that can't be fetched are left out
+// and returned; the error is only non-nil if no bundle could be written.
+func BuildBundle(ctx context.Context, p *PokeAPIWrapper, w io.Writer, kinds []ResourceKind, opts CrawlOptions) ([]*CrawlError, error) {
+	zw := zip.NewWriter(w)
+	var failed []*CrawlError
+	for _, kind := range kinds {
+		list, err := p.List(ctx, kind, Page{Offset: 0, Limit: nameIndexLimit})
+		if err != nil {
+			return failed, err
+		}
+		result, err := Crawl[bundleEntry](ctx, p, kind, opts)
+		if err != nil {
+			return failed, err
+		}
+		failed = append(failed, result.Errors...)
+
+		if err := writeBundleFile(zw, path.Join(string(kind), bundleIndexName), list); err != nil {
+			return failed, err
+		}
+		for _, entry := range result.Items {
+			name := path.Join(string(kind), strconv.Itoa(entry.ID)+".json")
+			if err := writeBundleFile(zw, name, json.RawMessage(entry.data)); err != nil {
+				return failed, err
+			}
+		}
+	}
+	return failed, zw.Close()
+}
+
+func writeBundleFile(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(v)
+}
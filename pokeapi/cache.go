@@ -0,0 +1,183 @@
+package pokeapi
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheEntry describes a response held in the client's caches.
+type CacheEntry struct {
+	URL      string
+	Size     int64
+	InMemory bool
+	OnDisk   bool
+	// Expires is when the response stops being fresh, if known.
+	Expires      time.Time
+	ETag         string
+	LastModified string
+}
+
+// CacheStats describes the contents of the client's caches and how its
+// requests were served.
+type CacheStats struct {
+	Metrics
+	MemoryEntries int
+	// MemoryBytes is the size of the memory cache as stored, which is less
+	// than MemoryUncompressedBytes when responses are compressed.
+	MemoryBytes             int
+	MemoryUncompressedBytes int
+	MemoryEvictions         int64
+	MemoryExpired           int64
+	// MemoryObjects counts the responses kept decoded as well, and
+	// MemoryObjectBytes is their estimated size, part of the memory budget.
+	MemoryObjects     int
+	MemoryObjectBytes int
+	// DiskEnabled is false when responses aren't kept on disk.
+	DiskEnabled bool
+	DiskEntries int
+	DiskBytes   int64
+}
+
+// CacheStats returns the sizes of the memory and disk caches along with
+// the client's request metrics.
+func (p *PokeAPIWrapper) CacheStats() (CacheStats, error) {
+	memory := p.cache.Stats()
+	stats := CacheStats{
+		Metrics:                 p.Metrics(),
+		MemoryEntries:           memory.Entries,
+		MemoryBytes:             memory.Bytes,
+		MemoryUncompressedBytes: memory.UncompressedBytes,
+		MemoryEvictions:         memory.Evictions,
+		MemoryExpired:           memory.Expired,
+		MemoryObjects:           memory.Attached,
+		MemoryObjectBytes:       memory.AttachedBytes,
+	}
+	if p.disk == nil {
+		return stats, nil
+	}
+	stats.DiskEnabled = true
+	entries, err := p.disk.Entries()
+	if err != nil {
+		return stats, err
+	}
+	stats.DiskEntries = len(entries)
+	for _, entry := range entries {
+		stats.DiskBytes += entry.Size
+	}
+	return stats, nil
+}
+
+// CacheEntries describes the cached responses whose URL starts with
+// prefix, sorted by URL.
+func (p *PokeAPIWrapper) CacheEntries(prefix string) ([]CacheEntry, error) {
+	byURL := make(map[string]*CacheEntry)
+	for _, info := range p.cache.Entries() {
+		if strings.HasPrefix(info.Key, prefix) {
+			byURL[info.Key] = &CacheEntry{
+				URL:      info.Key,
+				Size:     int64(info.Size - len(info.Key)),
+				InMemory: true,
+				Expires:  info.Expires,
+			}
+		}
+	}
+	if p.disk != nil {
+		diskEntries, err := p.disk.Entries()
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range diskEntries {
+			if !strings.HasPrefix(info.Key, prefix) {
+				continue
+			}
+			if entry, ok := byURL[info.Key]; ok {
+				entry.OnDisk = true
+				continue
+			}
+			byURL[info.Key] = &CacheEntry{URL: info.Key, Size: info.Size, OnDisk: true}
+		}
+	}
+
+	entries := make([]CacheEntry, 0, len(byURL))
+	for _, entry := range byURL {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URL < entries[j].URL
+	})
+	return entries, nil
+}
+
+// CachedResponse returns the cached body of fullURL, fresh or not, without
+// making a request.
+func (p *PokeAPIWrapper) CachedResponse(fullURL string) (CacheEntry, []byte, bool) {
+	entry := CacheEntry{URL: fullURL}
+	body, info, ok := p.cache.Peek(fullURL)
+	if ok {
+		entry.InMemory = true
+		entry.Expires = info.Expires
+	}
+	if p.disk != nil {
+		if data, ok := p.disk.Get(fullURL); ok {
+			var cached cachedResponse
+			if err := cached.UnmarshalBinary(data); err == nil {
+				entry.OnDisk = true
+				entry.ETag = cached.ETag
+				entry.LastModified = cached.LastModified
+				if !entry.InMemory {
+					entry.Expires = cached.Expires
+					body = cached.Body
+				}
+			}
+		}
+	}
+	entry.Size = int64(len(body))
+	return entry, body, entry.InMemory || entry.OnDisk
+}
+
+// Evict removes urlOrPrefix from the caches if it is a cached URL, and
+// otherwise every cached URL starting with it. It returns the number of
+// URLs removed.
+func (p *PokeAPIWrapper) Evict(urlOrPrefix string) (int, error) {
+	if _, _, ok := p.CachedResponse(urlOrPrefix); ok {
+		return 1, p.evict(urlOrPrefix)
+	}
+	entries, err := p.CacheEntries(urlOrPrefix)
+	if err != nil {
+		return 0, err
+	}
+	for i, entry := range entries {
+		if err := p.evict(entry.URL); err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+func (p *PokeAPIWrapper) evict(fullURL string) error {
+	p.cache.Delete(fullURL)
+	if p.stale != nil {
+		p.stale.Delete(fullURL)
+	}
+	if p.disk != nil {
+		return p.disk.Delete(fullURL)
+	}
+	return nil
+}
+
+// ClearCache empties the memory and disk caches and forgets the name
+// indexes used for suggestions.
+func (p *PokeAPIWrapper) ClearCache() error {
+	p.cache.Clear()
+	if p.stale != nil {
+		p.stale.Clear()
+	}
+	p.namesMux.Lock()
+	p.names = make(map[ResourceKind][]string)
+	p.namesMux.Unlock()
+	if p.disk != nil {
+		return p.disk.Clear()
+	}
+	return nil
+}
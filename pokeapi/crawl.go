@@ -0,0 +1,145 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// CrawlProgress reports how far a crawl has got.
+type CrawlProgress struct {
+	Kind   ResourceKind
+	Done   int
+	Failed int
+	Total  int
+}
+
+// CrawlOptions configures Crawl. The zero value crawls with DefaultWorkers
+// and no progress reporting.
+type CrawlOptions struct {
+	Workers int
+	// Progress, if set, is called after each resource is fetched, never
+	// concurrently.
+	Progress func(CrawlProgress)
+}
+
+// CrawlError records a resource a crawl couldn't fetch.
+type CrawlError struct {
+	Resource NamedAPIResource
+	Err      error
+}
+
+func (e *CrawlError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CrawlError) Unwrap() error {
+	return e.Err
+}
+
+// CrawlResult holds the resources a crawl fetched and the ones it couldn't,
+// both in list order.
+type CrawlResult[T any] struct {
+	Items  []T
+	Errors []*CrawlError
+}
+
+// Crawl fetches every resource of a list endpoint, decoding each into a T,
+// e.g. Crawl[Pokemon](ctx, api, KindPokemon, CrawlOptions{}). Resources
+// that fail are collected in the result's Errors rather than stopping the
+// crawl. With a disk cache, fetched resources are kept as the crawl goes,
+// so an interrupted crawl picks up where it stopped when run again: any
+// resource on disk counts as crawled, even once it is no longer fresh.
+//
+// The returned error is only non-nil if the list itself can't be fetched
+// or ctx is done before the crawl finishes.
+func Crawl[T any](ctx context.Context, p *PokeAPIWrapper, kind ResourceKind, opts CrawlOptions) (CrawlResult[T], error) {
+	list, err := p.List(ctx, kind, Page{Offset: 0, Limit: nameIndexLimit})
+	if err != nil {
+		return CrawlResult[T]{}, err
+	}
+	resources := list.Results
+
+	items := make([]T, len(resources))
+	ok := make([]bool, len(resources))
+	errs := make([]*CrawlError, len(resources))
+	progress := CrawlProgress{Kind: kind, Total: len(resources)}
+	// remaining holds the resources not crawled before, and remainingIndex
+	// where each is in resources.
+	var remaining []NamedAPIResource
+	var remainingIndex []int
+	for i, r := range resources {
+		if item, found := checkpoint[T](p, r); found {
+			items[i], ok[i] = item, true
+			progress.Done++
+			continue
+		}
+		remaining = append(remaining, r)
+		remainingIndex = append(remainingIndex, i)
+	}
+	if progress.Done > 0 && opts.Progress != nil {
+		opts.Progress(progress)
+	}
+
+	var mux sync.Mutex
+	resolveEach(ctx, p, remaining, opts.Workers, func(indexes []int, result T, err error) {
+		mux.Lock()
+		defer mux.Unlock()
+		for _, j := range indexes {
+			i := remainingIndex[j]
+			if err != nil {
+				errs[i] = &CrawlError{Resource: resources[i], Err: err}
+				progress.Failed++
+			} else {
+				items[i] = result
+				ok[i] = true
+			}
+			progress.Done++
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	})
+
+	var result CrawlResult[T]
+	for i, item := range items {
+		if ok[i] {
+			result.Items = append(result.Items, item)
+		}
+		if errs[i] != nil {
+			result.Errors = append(result.Errors, errs[i])
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("crawl of %s interrupted: %w", kind, err)
+	}
+	return result, nil
+}
+
+// checkpoint decodes the copy of the resource r links to that the disk
+// cache holds, fresh or not.
+func checkpoint[T any](p *PokeAPIWrapper, r NamedAPIResource) (T, bool) {
+	var result T
+	if p.disk == nil {
+		return result, false
+	}
+	fullURL, err := p.resolveURL(r.URL)
+	if err != nil {
+		return result, false
+	}
+	data, ok := p.disk.Get(fullURL)
+	if !ok {
+		return result, false
+	}
+	var cached cachedResponse
+	if err := cached.UnmarshalBinary(data); err != nil {
+		return result, false
+	}
+	if err := json.Unmarshal(cached.Body, &result); err != nil {
+		var noop T
+		return noop, false
+	}
+	p.hits.Add(1)
+	return result, true
+}
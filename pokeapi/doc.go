@@ -0,0 +1,14 @@
+// Package pokeapi is a typed client for PokeAPI (https://pokeapi.co).
+//
+// Resources are fetched by name or ID and decoded into Go structs:
+//
+//	api := pokeapi.NewPokeAPIWrapper(5 * time.Minute)
+//	pikachu, err := api.Pokemon(ctx, "Pikachu")
+//
+// Names are normalized, so "Mr. Mime" finds "mr-mime", and unknown names
+// return a *NotFoundError with spelling suggestions. List endpoints are
+// paged with List, and the NamedAPIResource links inside responses can be
+// followed with Resolve or ResolveAs, or concurrently with ResolveAll.
+// Responses are cached in memory for the interval given to
+// NewPokeAPIWrapper.
+package pokeapi
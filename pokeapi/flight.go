@@ -0,0 +1,71 @@
+package pokeapi
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup coalesces concurrent fetches of the same URL into one.
+type flightGroup struct {
+	mux   sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a fetch in progress; done is closed once val and err are set.
+type flight struct {
+	done chan struct{}
+	val  []byte
+	err  error
+	// waiters counts the callers waiting for the fetch; the last one to
+	// give up cancels it.
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fetch for key unless a fetch for key is already in flight, in
+// which case it waits for that one's result instead and reports shared.
+// fetch runs without ctx's cancellation so a caller giving up doesn't fail
+// the others sharing it; do itself returns early when ctx is done, and the
+// fetch is cancelled once no caller is waiting for it.
+func (g *flightGroup) do(ctx context.Context, key string, fetch func(context.Context) ([]byte, error)) (val []byte, err error, shared bool) {
+	g.mux.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	f, shared := g.calls[key]
+	if !shared {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = f
+		go func() {
+			f.val, f.err = fetch(fetchCtx)
+			cancel()
+			g.mux.Lock()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+			g.mux.Unlock()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mux.Unlock()
+
+	select {
+	case <-f.done:
+		return f.val, f.err, shared
+	case <-ctx.Done():
+		g.mux.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Later callers start a fetch of their own rather than join
+			// the cancelled one.
+			f.cancel()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+		}
+		g.mux.Unlock()
+		return nil, ctx.Err(), shared
+	}
+}
@@ -0,0 +1,18 @@
+package pokeapi
+
+import "context"
+
+// Generation is a generation of the games, with the region it introduced
+// and the species that first appeared in it.
+type Generation struct {
+	ID             int                `json:"id"`
+	Name           string             `json:"name"`
+	MainRegion     NamedAPIResource   `json:"main_region"`
+	PokemonSpecies []NamedAPIResource `json:"pokemon_species"`
+}
+
+// Generation fetches a generation by name, such as "generation-iv", or ID.
+// A *NotFoundError with suggestions is returned for unknown names.
+func (p *PokeAPIWrapper) Generation(ctx context.Context, nameOrID string) (Generation, error) {
+	return lookup[Generation](ctx, p, KindGeneration, nameOrID)
+}
@@ -1,9 +1,5 @@
 package pokeapi
 
-import (
-	"fmt"
-)
-
 type LocationArea struct {
 	// EncounterMethodRates []struct {
 	// 	EncounterMethod struct {
@@ -53,7 +49,3 @@ type LocationArea struct {
 		// } `json:"version_details"`
 	} `json:"pokemon_encounters"`
 }
-
-func GetLocationAreaURLByName(locationAreaName string) string {
-	return fmt.Sprintf("%s/%s", GetLocationAreasPageBaseURL(), locationAreaName)
-}
@@ -0,0 +1,35 @@
+package pokeapi
+
+import "context"
+
+// Move is a move Pokemon can learn.
+type Move struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Power is 0 for moves that don't deal damage directly.
+	Power       int              `json:"power"`
+	Type        NamedAPIResource `json:"type"`
+	DamageClass NamedAPIResource `json:"damage_class"`
+}
+
+// Damaging reports whether the move deals damage, as opposed to a status
+// move such as Growl.
+func (m Move) Damaging() bool {
+	return m.DamageClass.Name != "status"
+}
+
+// Move fetches a move by name or ID. A *NotFoundError with suggestions is
+// returned for unknown names.
+func (p *PokeAPIWrapper) Move(ctx context.Context, nameOrID string) (Move, error) {
+	return lookup[Move](ctx, p, KindMove, nameOrID)
+}
+
+// MoveResources returns links to the moves the Pokemon can learn, for
+// ResolveAll.
+func (p Pokemon) MoveResources() []NamedAPIResource {
+	resources := make([]NamedAPIResource, len(p.Moves))
+	for i, m := range p.Moves {
+		resources[i] = m.Move
+	}
+	return resources
+}
@@ -0,0 +1,195 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResourceKind names a PokeAPI list endpoint, e.g. "pokemon" for /pokemon.
+type ResourceKind string
+
+const (
+	KindPokemon      ResourceKind = "pokemon"
+	KindLocationArea ResourceKind = "location-area"
+	KindMove         ResourceKind = "move"
+	KindItem         ResourceKind = "item"
+	KindType         ResourceKind = "type"
+	KindGeneration   ResourceKind = "generation"
+	KindPokedex      ResourceKind = "pokedex"
+)
+
+const (
+	nameIndexLimit = 100000
+	maxSuggestions = 3
+)
+
+// NotFoundError is returned by the resource methods when the API has no
+// resource with the requested name. Suggestions holds the closest known
+// names, best match first.
+type NotFoundError struct {
+	Kind        ResourceKind
+	Name        string
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	msg := fmt.Sprintf("%s %q not found", e.Kind, e.Name)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf("; did you mean %s?", strings.Join(e.Suggestions, ", "))
+	}
+	return msg
+}
+
+// NormalizeName turns user input such as "Mr. Mime" or "Farfetch'd" into
+// the hyphenated lowercase form the API uses ("mr-mime", "farfetchd").
+// Numeric IDs are returned without leading zeros.
+func NormalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if id, err := strconv.Atoi(name); err == nil && id >= 0 {
+		return strconv.Itoa(id)
+	}
+
+	replacer := strings.NewReplacer(
+		"♀", "-f",
+		"♂", "-m",
+		"é", "e",
+		".", " ",
+		"'", "",
+		"’", "",
+		":", "",
+		"_", " ",
+	)
+	name = replacer.Replace(name)
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-'
+	}), "-")
+}
+
+func (k ResourceKind) resourceURL(baseURL, name string) string {
+	return fmt.Sprintf("%s/%s/%s", baseURL, k, name)
+}
+
+// Names returns every resource name of the given kind. The list is fetched
+// once and kept for the lifetime of the wrapper. Concurrent calls for the
+// same kind share the fetch of its list, like any other request for the
+// same URL, while other kinds don't wait for it.
+func (p *PokeAPIWrapper) Names(ctx context.Context, kind ResourceKind) ([]string, error) {
+	p.namesMux.Lock()
+	names, ok := p.names[kind]
+	p.namesMux.Unlock()
+	if ok {
+		return names, nil
+	}
+
+	list, err := p.List(ctx, kind, Page{Offset: 0, Limit: nameIndexLimit})
+	if err != nil {
+		return nil, err
+	}
+	names = make([]string, len(list.Results))
+	for i, resource := range list.Results {
+		names[i] = resource.Name
+	}
+	p.namesMux.Lock()
+	p.names[kind] = names
+	p.namesMux.Unlock()
+	return names, nil
+}
+
+// Suggest returns up to n known names of the given kind closest to name by
+// edit distance.
+func (p *PokeAPIWrapper) Suggest(ctx context.Context, kind ResourceKind, name string, n int) []string {
+	names, err := p.Names(ctx, kind)
+	if err != nil {
+		return nil
+	}
+	return closestNames(NormalizeName(name), names, n)
+}
+
+func closestNames(name string, candidates []string, n int) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	maxDistance := max(2, len(name)/3)
+	var matches []match
+	for _, candidate := range candidates {
+		d := levenshtein(name, candidate)
+		if strings.HasPrefix(candidate, name) && len(name) >= 3 {
+			d = min(d, 1)
+		}
+		if d <= maxDistance {
+			matches = append(matches, match{candidate, d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	suggestions := make([]string, 0, n)
+	for i := 0; i < len(matches) && i < n; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func lookup[T any](ctx context.Context, p *PokeAPIWrapper, kind ResourceKind, nameOrID string) (T, error) {
+	name := NormalizeName(nameOrID)
+	if name == "" {
+		var noop T
+		return noop, fmt.Errorf("%s name must not be empty", kind)
+	}
+
+	result, err := getStructFromURL[T](ctx, p, kind.resourceURL(p.BaseURL, name))
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == 404 {
+		var noop T
+		return noop, &NotFoundError{
+			Kind:        kind,
+			Name:        name,
+			Suggestions: p.Suggest(ctx, kind, name, maxSuggestions),
+		}
+	}
+	return result, err
+}
+
+// Pokemon fetches a Pokemon by name or ID, accepting loose spellings such
+// as "Mr. Mime". A *NotFoundError with suggestions is returned for unknown
+// names.
+func (p *PokeAPIWrapper) Pokemon(ctx context.Context, nameOrID string) (Pokemon, error) {
+	return lookup[Pokemon](ctx, p, KindPokemon, nameOrID)
+}
+
+// LocationArea fetches a location area by name or ID. A *NotFoundError
+// with suggestions is returned for unknown names.
+func (p *PokeAPIWrapper) LocationArea(ctx context.Context, nameOrID string) (LocationArea, error) {
+	return lookup[LocationArea](ctx, p, KindLocationArea, nameOrID)
+}
@@ -0,0 +1,41 @@
+package pokeapi
+
+import (
+	"fmt"
+)
+
+// DefaultPageLimit is the number of results per page the API returns when
+// no limit is given.
+const DefaultPageLimit = 20
+
+// Page selects a window of a list endpoint.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// FirstPage returns the first page of DefaultPageLimit results.
+func FirstPage() Page {
+	return Page{Offset: 0, Limit: DefaultPageLimit}
+}
+
+// Next returns the page following p.
+func (p Page) Next() Page {
+	return Page{Offset: p.Offset + p.limit(), Limit: p.limit()}
+}
+
+// Previous returns the page before p, or the first page if p is the first.
+func (p Page) Previous() Page {
+	return Page{Offset: max(0, p.Offset-p.limit()), Limit: p.limit()}
+}
+
+func (p Page) limit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	return p.Limit
+}
+
+func (k ResourceKind) pageURL(baseURL string, page Page) string {
+	return fmt.Sprintf("%s/%s?offset=%d&limit=%d", baseURL, k, page.Offset, page.limit())
+}
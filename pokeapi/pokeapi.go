@@ -0,0 +1,435 @@
+package pokeapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/pokecache"
+)
+
+const (
+	// DefaultBaseURL is the public PokeAPI.
+	DefaultBaseURL = "https://pokeapi.co/api/v2"
+
+	// staleRetention is how long past its freshness a client without a
+	// disk cache keeps a response to revalidate it.
+	staleRetention = 24 * time.Hour
+)
+
+// PokeAPIWrapper is a PokeAPI client. Its methods are safe for concurrent
+// use.
+type PokeAPIWrapper struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	cache *pokecache.Cache
+	// disk, if set, keeps responses across runs.
+	disk *pokecache.DiskCache
+	// stale keeps responses with their validators past their freshness
+	// when there is no disk cache, so they can still be revalidated.
+	stale *pokecache.Cache
+
+	// offline is set when the client is served by a bundle.
+	offline bool
+
+	names    map[ResourceKind][]string
+	namesMux sync.Mutex
+
+	cacheOptions  []pokecache.Option
+	cacheInterval time.Duration
+	listTTL       time.Duration
+
+	flights                              flightGroup
+	hits, misses, coalesced, revalidated atomic.Int64
+}
+
+// Metrics counts how the client's requests were served.
+type Metrics struct {
+	// Hits were served from the memory or disk cache.
+	Hits int64
+	// Misses were fetched from the network.
+	Misses int64
+	// Coalesced waited for a fetch of the same URL already in flight
+	// instead of making their own.
+	Coalesced int64
+	// Revalidated misses found their stale cached copy still current.
+	Revalidated int64
+}
+
+// Metrics returns the client's request counts so far.
+func (p *PokeAPIWrapper) Metrics() Metrics {
+	return Metrics{
+		Hits:        p.hits.Load(),
+		Misses:      p.misses.Load(),
+		Coalesced:   p.coalesced.Load(),
+		Revalidated: p.revalidated.Load(),
+	}
+}
+
+// Option configures a PokeAPIWrapper.
+type Option func(*PokeAPIWrapper)
+
+// WithBaseURL points the client at a PokeAPI mirror. Links in responses
+// that refer to DefaultBaseURL are rewritten to it.
+func WithBaseURL(baseURL string) Option {
+	return func(p *PokeAPIWrapper) {
+		p.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithDiskCache keeps responses as files in dir, so later runs and
+// interrupted crawls don't fetch them again.
+func WithDiskCache(dir string) Option {
+	return func(p *PokeAPIWrapper) {
+		p.disk = pokecache.NewDiskCache(dir)
+	}
+}
+
+// WithMemoryBudget bounds the in-memory cache to maxBytes of responses and
+// maxEntries responses, evicting the least recently used ones beyond that.
+// Without a disk cache, the stale responses kept to be revalidated are
+// bounded the same way. Zero means no bound.
+func WithMemoryBudget(maxBytes, maxEntries int) Option {
+	return func(p *PokeAPIWrapper) {
+		p.cacheOptions = append(p.cacheOptions,
+			pokecache.WithMaxBytes(maxBytes),
+			pokecache.WithMaxEntries(maxEntries),
+		)
+	}
+}
+
+// WithCompression gzips responses of at least threshold bytes held in
+// memory, which mostly pays off for large resources such as pokemon.
+func WithCompression(threshold int) Option {
+	return func(p *PokeAPIWrapper) {
+		p.cacheOptions = append(p.cacheOptions, pokecache.WithCompression(threshold))
+	}
+}
+
+// WithListTTL keeps list pages fresh for ttl instead of the cache interval,
+// so they can be refreshed sooner than the resources they list. A max-age
+// from the server takes precedence.
+func WithListTTL(ttl time.Duration) Option {
+	return func(p *PokeAPIWrapper) {
+		p.listTTL = ttl
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are made with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *PokeAPIWrapper) {
+		p.HTTPClient = client
+	}
+}
+
+type NamedAPIResourceList struct {
+	Count    int                `json:"count"`
+	Next     string             `json:"next"`
+	Previous string             `json:"previous"`
+	Results  []NamedAPIResource `json:"results"`
+}
+
+type NamedAPIResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// NewPokeAPIWrapper returns a client whose responses are cached for
+// cacheInterval, unless the server says how long they stay fresh.
+func NewPokeAPIWrapper(cacheInterval time.Duration, opts ...Option) *PokeAPIWrapper {
+	p := &PokeAPIWrapper{
+		BaseURL:       DefaultBaseURL,
+		HTTPClient:    http.DefaultClient,
+		names:         make(map[ResourceKind][]string),
+		cacheInterval: cacheInterval,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.cache = pokecache.NewCache(cacheInterval, p.cacheOptions...)
+	if p.disk == nil && !p.offline {
+		p.stale = pokecache.NewCache(cacheInterval, p.cacheOptions...)
+	}
+	return p
+}
+
+// Close stops the client's background cache maintenance.
+func (p *PokeAPIWrapper) Close() {
+	p.cache.Close()
+	if p.stale != nil {
+		p.stale.Close()
+	}
+}
+
+// StatusError is returned when the API answers with anything but 200 OK.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// resolveURL makes a link from a response absolute and points it at
+// BaseURL if it refers to the public API.
+func (p *PokeAPIWrapper) resolveURL(link string) (string, error) {
+	if rest, ok := strings.CutPrefix(link, DefaultBaseURL); ok {
+		return p.BaseURL + rest, nil
+	}
+	base, err := url.Parse(p.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// getBytesFromURL returns the body of a successful GET of fullURL, from the
+// memory or disk cache if possible. A stale copy, on disk or kept in memory
+// without a disk cache, is revalidated with a conditional request rather
+// than downloaded again. Concurrent calls
+// for the same URL that miss the cache share a single request.
+func (p *PokeAPIWrapper) getBytesFromURL(ctx context.Context, fullURL string) ([]byte, error) {
+	if cachedData, _, ok := p.getCached(fullURL); ok {
+		p.hits.Add(1)
+		return cachedData, nil
+	}
+
+	data, err, shared := p.flights.do(ctx, fullURL, func(ctx context.Context) ([]byte, error) {
+		// Another caller's request may have completed since the check.
+		cachedData, stale, ok := p.getCached(fullURL)
+		if ok {
+			p.hits.Add(1)
+			return cachedData, nil
+		}
+		p.misses.Add(1)
+		return p.fetch(ctx, fullURL, stale)
+	})
+	if shared {
+		p.coalesced.Add(1)
+	}
+	return data, err
+}
+
+// getCached returns the cached body of fullURL if it is fresh. Otherwise
+// it returns the stale copy kept with its validators, if any.
+func (p *PokeAPIWrapper) getCached(fullURL string) ([]byte, *cachedResponse, bool) {
+	if cachedData, ok := p.cache.Get(fullURL); ok {
+		return cachedData, nil, true
+	}
+	var data []byte
+	var ok bool
+	switch {
+	case p.disk != nil:
+		data, ok = p.disk.Get(fullURL)
+	case p.stale != nil:
+		data, ok = p.stale.Get(fullURL)
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	var cached cachedResponse
+	if err := cached.UnmarshalBinary(data); err != nil {
+		return nil, nil, false
+	}
+	now := time.Now()
+	if !cached.fresh(now) {
+		return nil, &cached, false
+	}
+	p.cache.AddWithTTL(fullURL, cached.Body, cached.Expires.Sub(now))
+	return cached.Body, nil, true
+}
+
+// ttl returns how long a response stays fresh: its max-age if the server
+// gave one, otherwise the list TTL for pages of list endpoints and the
+// cache interval for everything else.
+func (p *PokeAPIWrapper) ttl(fullURL string, header http.Header) time.Duration {
+	if age, ok := maxAge(header); ok {
+		return age
+	}
+	if u, err := url.Parse(fullURL); err == nil && p.listTTL > 0 && u.Query().Has("offset") {
+		return p.listTTL
+	}
+	return p.cacheInterval
+}
+
+// fetch GETs fullURL from the network and caches the response. If stale is
+// set, the request is conditional and a 304 Not Modified response renews
+// stale instead.
+func (p *PokeAPIWrapper) fetch(ctx context.Context, fullURL string, stale *cachedResponse) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Sprites and other files linked from resources aren't JSON.
+	if strings.HasPrefix(fullURL, p.BaseURL+"/") {
+		req.Header.Set("Accept", "application/json")
+	}
+	if stale != nil {
+		stale.setConditional(req)
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	cached := &cachedResponse{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && stale != nil:
+		p.revalidated.Add(1)
+		cached.Body = stale.Body
+		if cached.ETag == "" {
+			cached.ETag = stale.ETag
+		}
+		if cached.LastModified == "" {
+			cached.LastModified = stale.LastModified
+		}
+	case resp.StatusCode == http.StatusOK:
+		// Read the response body into a byte slice
+		cached.Body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response body: \n%v", err)
+		}
+	default:
+		return nil, &StatusError{URL: fullURL, StatusCode: resp.StatusCode}
+	}
+
+	ttl := p.ttl(fullURL, resp.Header)
+	cached.Expires = time.Now().Add(ttl)
+	p.cache.AddWithTTL(fullURL, cached.Body, ttl)
+	data, _ := cached.MarshalBinary()
+	switch {
+	case p.disk != nil:
+		// The response is good even if it can't be kept on disk; it is
+		// fetched again next time.
+		_ = p.disk.Add(fullURL, data)
+	case p.stale != nil && cached.validated():
+		p.stale.AddWithTTL(fullURL, data, ttl+staleRetention)
+	}
+	return cached.Body, nil
+}
+
+// getStructFromURL returns the response for fullURL decoded into a T. While
+// the response is in the memory cache, the decoded T is attached to it and
+// returned again instead of decoding the response each time. It counts
+// against the cache's budget as much as the JSON it was decoded from.
+//
+// Values are shared between callers, so their slices and maps must not be
+// modified.
+func getStructFromURL[T any](ctx context.Context, p *PokeAPIWrapper, fullURL string) (T, error) {
+	typ := reflect.TypeFor[T]()
+	if v, ok := p.cache.Attached(fullURL, typ); ok {
+		p.hits.Add(1)
+		return v.(T), nil
+	}
+
+	var result T
+	data, err := p.getBytesFromURL(ctx, fullURL)
+	if err != nil {
+		return result, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&result); err != nil {
+		var noop T
+		return noop, fmt.Errorf("error decoding JSON: \n%v", err)
+	}
+	p.cache.Attach(fullURL, data, typ, result, len(data))
+	return result, nil
+}
+
+// List returns one page of the resources of the given kind.
+func (p *PokeAPIWrapper) List(ctx context.Context, kind ResourceKind, page Page) (NamedAPIResourceList, error) {
+	fullURL := kind.pageURL(p.BaseURL, page)
+	n, err := getStructFromURL[NamedAPIResourceList](ctx, p, fullURL)
+	if err != nil {
+		return NamedAPIResourceList{}, fmt.Errorf(
+			"failed to list %s: %w", kind, err,
+		)
+	}
+	return n, nil
+}
+
+// Resolve fetches the resource r links to and decodes it into v, which
+// must be a pointer, e.g. a *Pokemon for the results of List(ctx,
+// KindPokemon, page).
+func (r NamedAPIResource) Resolve(ctx context.Context, p *PokeAPIWrapper, v any) error {
+	fullURL, err := p.resolveURL(r.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL for %s: %w", r.Name, err)
+	}
+	data, err := p.getBytesFromURL(ctx, fullURL)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", r.Name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding %s: %w", r.Name, err)
+	}
+	return nil
+}
+
+// ID returns the numeric ID at the end of the resource's URL, or 0 if
+// there is none.
+func (r NamedAPIResource) ID() int {
+	last := strings.TrimSuffix(r.URL, "/")
+	last = last[strings.LastIndex(last, "/")+1:]
+	id, err := strconv.Atoi(last)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// FindMinMaxBaseExperience returns the lowest and highest base experience
+// of pokemons, e.g. the Items of a Crawl of KindPokemon.
+func FindMinMaxBaseExperience(pokemons []Pokemon) (int, int) {
+	min := math.MaxInt
+	max := 0
+	for _, pokemon := range pokemons {
+		if pokemon.BaseExperience < min {
+			min = pokemon.BaseExperience
+		}
+		if pokemon.BaseExperience > max {
+			max = pokemon.BaseExperience
+		}
+	}
+	return min, max
+}
+
+// TypeNames returns the names of the Pokemon's types, primary type first.
+func (p Pokemon) TypeNames() []string {
+	names := make([]string, len(p.Types))
+	for i, t := range p.Types {
+		names[i] = t.Type.Name
+	}
+	return names
+}
+
+// BaseStatTotal returns the sum of the Pokemon's base stats.
+func (p Pokemon) BaseStatTotal() int {
+	total := 0
+	for _, stat := range p.Stats {
+		total += stat.BaseStat
+	}
+	return total
+}
@@ -0,0 +1,823 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNormalizeName(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Pikachu", expected: "pikachu"},
+		{input: "  Mr. Mime ", expected: "mr-mime"},
+		{input: "Farfetch'd", expected: "farfetchd"},
+		{input: "Nidoran♀", expected: "nidoran-f"},
+		{input: "type: null", expected: "type-null"},
+		{input: "eterna_forest area", expected: "eterna-forest-area"},
+		{input: "025", expected: "25"},
+	}
+
+	for _, c := range cases {
+		actual := NormalizeName(c.input)
+		if actual != c.expected {
+			t.Errorf("NormalizeName(%q): expected %q, got %q", c.input, c.expected, actual)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "abc", expected: 3},
+		{a: "pikachu", b: "pikachu", expected: 0},
+		{a: "pikchu", b: "pikachu", expected: 1},
+		{a: "kitten", b: "sitting", expected: 3},
+	}
+
+	for _, c := range cases {
+		actual := levenshtein(c.a, c.b)
+		if actual != c.expected {
+			t.Errorf("levenshtein(%q, %q): expected %d, got %d", c.a, c.b, c.expected, actual)
+		}
+	}
+}
+
+func TestClosestNames(t *testing.T) {
+	names := []string{"pikachu", "raichu", "pichu", "charmander", "bulbasaur"}
+
+	actual := closestNames("pikchu", names, 3)
+	expected := []string{"pichu", "pikachu"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	actual = closestNames("zzzzzz", names, 3)
+	if len(actual) != 0 {
+		t.Errorf("expected no suggestions, got %v", actual)
+	}
+}
+
+func TestLookupPokemonNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pokemon":
+			fmt.Fprint(w, `{"count":2,"results":[
+				{"name":"pikachu","url":"/pokemon/25/"},
+				{"name":"mr-mime","url":"/pokemon/122/"}
+			]}`)
+		case "/pokemon/mr-mime":
+			fmt.Fprint(w, `{"id":122,"name":"mr-mime"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer api.Close()
+
+	pokemon, err := api.Pokemon(context.Background(), "Mr. Mime")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.ID != 122 {
+		t.Errorf("expected ID 122, got %d", pokemon.ID)
+	}
+
+	_, err = api.Pokemon(context.Background(), "pikchu")
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+	if !reflect.DeepEqual(notFound.Suggestions, []string{"pikachu"}) {
+		t.Errorf("expected suggestion pikachu, got %v", notFound.Suggestions)
+	}
+}
+
+func TestListAndResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pokemon":
+			if r.URL.Query().Get("offset") != "20" || r.URL.Query().Get("limit") != "20" {
+				t.Errorf("unexpected query %q", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"count":21,"results":[
+				{"name":"pikachu","url":"https://pokeapi.co/api/v2/pokemon/25/"}
+			]}`)
+		case "/pokemon/25/":
+			fmt.Fprint(w, `{"id":25,"name":"pikachu"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer api.Close()
+	list, err := api.List(context.Background(), KindPokemon, FirstPage().Next())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Results) != 1 || list.Results[0].ID() != 25 {
+		t.Fatalf("unexpected results %+v", list.Results)
+	}
+
+	var pokemon Pokemon
+	if err := list.Results[0].Resolve(context.Background(), api, &pokemon); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.Name != "pikachu" {
+		t.Errorf("expected pikachu, got %q", pokemon.Name)
+	}
+}
+
+func TestPage(t *testing.T) {
+	page := FirstPage().Next().Next()
+	if page != (Page{Offset: 40, Limit: DefaultPageLimit}) {
+		t.Errorf("unexpected page %+v", page)
+	}
+	if page = (Page{Offset: 5, Limit: 20}).Previous(); page.Offset != 0 {
+		t.Errorf("expected offset 0, got %d", page.Offset)
+	}
+}
+
+func TestResolveAll(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = make(map[string]int)
+		inFlight int
+		maxSeen  int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		inFlight++
+		maxSeen = max(maxSeen, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Path == "/pokemon/0/" {
+			http.NotFound(w, r)
+			return
+		}
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/pokemon/"), "/")
+		fmt.Fprintf(w, `{"id":%s}`, id)
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer api.Close()
+	var links []NamedAPIResource
+	for _, id := range []int{1, 2, 3, 4, 5, 1, 0, 2} {
+		links = append(links, NamedAPIResource{
+			Name: strconv.Itoa(id),
+			URL:  fmt.Sprintf("%s/pokemon/%d/", DefaultBaseURL, id),
+		})
+	}
+
+	pokemons, err := ResolveAll[Pokemon](context.Background(), api, links, 2)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+	var ids []int
+	for _, pokemon := range pokemons {
+		ids = append(ids, pokemon.ID)
+	}
+	if expected := []int{1, 2, 3, 4, 5, 1, 0, 2}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected IDs %v, got %v", expected, ids)
+	}
+	for path, n := range requests {
+		if n != 1 {
+			t.Errorf("expected one request for %s, got %d", path, n)
+		}
+	}
+	if maxSeen > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", maxSeen)
+	}
+}
+
+func TestCrawlResumes(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = make(map[string]int)
+		failing  = true
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		fail := failing && r.URL.Path == "/pokemon/2/"
+		mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/pokemon":
+			fmt.Fprint(w, `{"count":3,"results":[
+				{"name":"bulbasaur","url":"/pokemon/1/"},
+				{"name":"ivysaur","url":"/pokemon/2/"},
+				{"name":"venusaur","url":"/pokemon/3/"}
+			]}`)
+		case fail:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			// Crawled resources are stale at once, yet still not fetched
+			// again when the crawl resumes.
+			w.Header().Set("Cache-Control", "max-age=0")
+			id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/pokemon/"), "/")
+			fmt.Fprintf(w, `{"id":%s,"base_experience":%s0}`, id, id)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL), WithDiskCache(dir))
+	defer api.Close()
+	var last CrawlProgress
+	result, err := Crawl[Pokemon](context.Background(), api, KindPokemon, CrawlOptions{
+		Workers:  2,
+		Progress: func(p CrawlProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Items) != 2 || len(result.Errors) != 1 || result.Errors[0].Resource.Name != "ivysaur" {
+		t.Fatalf("expected ivysaur to fail, got %+v", result)
+	}
+	if last != (CrawlProgress{Kind: KindPokemon, Done: 3, Failed: 1, Total: 3}) {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+
+	// A new client over the same disk cache only fetches what failed.
+	mu.Lock()
+	failing = false
+	requests = make(map[string]int)
+	mu.Unlock()
+	api = NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL), WithDiskCache(dir))
+	defer api.Close()
+	result, err = Crawl[Pokemon](context.Background(), api, KindPokemon, CrawlOptions{})
+	if err != nil || len(result.Items) != 3 || len(result.Errors) != 0 {
+		t.Fatalf("expected a complete crawl, got %+v, %v", result, err)
+	}
+	if !reflect.DeepEqual(requests, map[string]int{"/pokemon/2/": 1}) {
+		t.Errorf("expected only ivysaur to be fetched again, got %v", requests)
+	}
+	if minExp, maxExp := FindMinMaxBaseExperience(result.Items); minExp != 10 || maxExp != 30 {
+		t.Errorf("expected base experience 10 to 30, got %d to %d", minExp, maxExp)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pokemon":
+			fmt.Fprint(w, `{"count":3,"results":[
+				{"name":"bulbasaur","url":"/pokemon/1/"},
+				{"name":"ivysaur","url":"/pokemon/2/"},
+				{"name":"venusaur","url":"/pokemon/3/"}
+			]}`)
+		case "/pokemon/3/":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/pokemon/"), "/")
+			fmt.Fprintf(w, `{"id":%s,"name":"pokemon-%s"}`, id, id)
+		}
+	}))
+
+	name := filepath.Join(t.TempDir(), "bundle.zip")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	online := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer online.Close()
+	failed, err := BuildBundle(context.Background(), online, f, []ResourceKind{KindPokemon}, CrawlOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failed) != 1 || failed[0].Resource.Name != "venusaur" {
+		t.Errorf("expected venusaur to fail, got %v", failed)
+	}
+	f.Close()
+	server.Close()
+
+	bundle, err := OpenBundle(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer bundle.Close()
+	if kinds := bundle.Kinds(); !reflect.DeepEqual(kinds, []ResourceKind{KindPokemon}) {
+		t.Errorf("expected the bundle to hold pokemon, got %v", kinds)
+	}
+	api := NewPokeAPIWrapper(5*time.Second, WithBundle(bundle))
+	defer api.Close()
+	ctx := context.Background()
+
+	pokemon, err := api.Pokemon(ctx, "Ivysaur")
+	if err != nil || pokemon.ID != 2 {
+		t.Errorf("expected ivysaur, got %+v, %v", pokemon, err)
+	}
+
+	list, err := api.List(ctx, KindPokemon, Page{Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Count != 3 || len(list.Results) != 1 || list.Results[0].Name != "ivysaur" ||
+		list.Next == "" || list.Previous == "" {
+		t.Errorf("unexpected page %+v", list)
+	}
+	if err := list.Results[0].Resolve(ctx, api, &pokemon); err != nil {
+		t.Errorf("unexpected error resolving from bundle: %v", err)
+	}
+
+	var notFound *NotFoundError
+	if _, err := api.Pokemon(ctx, "ivysaurr"); !errors.As(err, &notFound) || len(notFound.Suggestions) == 0 {
+		t.Errorf("expected NotFoundError with suggestions, got %v", err)
+	}
+	if _, err := api.Pokemon(ctx, "venusaur"); err == nil || !strings.Contains(err.Error(), "not in the offline bundle") {
+		t.Errorf("expected missing resource error, got %v", err)
+	}
+	if _, err := api.LocationArea(ctx, "canalave-city-area"); !errors.Is(err, ErrNotInBundle) || !strings.Contains(err.Error(), "no data") {
+		t.Errorf("expected missing kind error, got %v", err)
+	}
+	if _, err := api.Sprite(ctx, "https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/2.png"); !errors.Is(err, ErrNotInBundle) {
+		t.Errorf("expected sprites not to be in the bundle, got %v", err)
+	}
+}
+
+func TestAcceptHeader(t *testing.T) {
+	accept := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept[r.URL.Path] = r.Header.Get("Accept")
+		fmt.Fprint(w, `{"id":25,"name":"pikachu"}`)
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL+"/api/v2"))
+	defer api.Close()
+	ctx := context.Background()
+	if _, err := api.Pokemon(ctx, "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := api.Sprite(ctx, server.URL+"/sprites/pokemon/25.png"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accept["/api/v2/pokemon/pikachu"] != "application/json" || accept["/sprites/pokemon/25.png"] != "" {
+		t.Errorf("expected JSON to be asked of the API only, got %v", accept)
+	}
+}
+
+// waiting returns the number of callers waiting for a fetch of key.
+func (g *flightGroup) waiting(key string) int {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if f, ok := g.calls[key]; ok {
+		return f.waiters
+	}
+	return 0
+}
+
+func TestCoalescedRequests(t *testing.T) {
+	var requests atomic.Int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		fmt.Fprint(w, `{"id":25,"name":"pikachu"}`)
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer api.Close()
+
+	// The first caller gives up; the fetch it started still serves the
+	// others.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := api.Pokemon(ctx, "pikachu")
+		first <- err
+	}()
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if pokemon, err := api.Pokemon(context.Background(), "pikachu"); err != nil || pokemon.ID != 25 {
+				t.Errorf("unexpected result %+v, %v", pokemon, err)
+			}
+		}()
+	}
+	for api.flights.waiting(server.URL+"/pokemon/pikachu") < callers+1 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+	m := api.Metrics()
+	if m.Misses != 1 || m.Hits+m.Coalesced != callers || m.Coalesced == 0 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+func TestNamesConcurrent(t *testing.T) {
+	var requests atomic.Int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/pokemon") {
+			requests.Add(1)
+			<-release
+		}
+		fmt.Fprint(w, `{"count":1,"results":[{"name":"pikachu"}]}`)
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer api.Close()
+
+	const callers = 5
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if names, err := api.Names(context.Background(), KindPokemon); err != nil || len(names) != 1 {
+				t.Errorf("unexpected result %v, %v", names, err)
+			}
+		}()
+	}
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Another kind's names don't wait for the pokemon list.
+	names, err := api.Names(context.Background(), KindMove)
+	if err != nil || len(names) != 1 {
+		t.Errorf("unexpected result %v, %v", names, err)
+	}
+	close(release)
+	wg.Wait()
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 request for the pokemon list, got %d", n)
+	}
+}
+
+func TestAbandonedFetch(t *testing.T) {
+	abandoned := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(abandoned)
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer api.Close()
+
+	// Once the only caller gives up, nothing waits for the fetch, so it is
+	// cancelled rather than left hanging.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := api.Pokemon(ctx, "pikachu"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	select {
+	case <-abandoned:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the abandoned request to be cancelled")
+	}
+	if n := api.flights.waiting(server.URL + "/pokemon/pikachu"); n != 0 {
+		t.Errorf("expected no fetch in flight, got %d waiting", n)
+	}
+}
+
+func TestRevalidation(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "disk", opts: []Option{WithDiskCache(t.TempDir())}},
+		// Without a disk cache, the stale response is kept in memory.
+		{name: "memory"},
+	}
+	for _, c := range cases {
+		var (
+			mu          sync.Mutex
+			downloads   int
+			notModified int
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "public, max-age=0")
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloads++
+			fmt.Fprint(w, `{"id":25,"name":"pikachu"}`)
+		}))
+
+		api := NewPokeAPIWrapper(time.Hour, append(c.opts, WithBaseURL(server.URL))...)
+		for i := 0; i < 3; i++ {
+			pokemon, err := api.Pokemon(context.Background(), "pikachu")
+			if err != nil || pokemon.ID != 25 {
+				t.Fatalf("%s: unexpected result %+v, %v", c.name, pokemon, err)
+			}
+		}
+		if downloads != 1 || notModified != 2 {
+			t.Errorf("%s: expected 1 download and 2 revalidations, got %d and %d", c.name, downloads, notModified)
+		}
+		if m := api.Metrics(); m.Revalidated != 2 {
+			t.Errorf("%s: expected 2 revalidated, got %+v", c.name, m)
+		}
+		api.Close()
+		server.Close()
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	cases := []struct {
+		header   string
+		expected time.Duration
+		ok       bool
+	}{
+		{header: "public, max-age=86400, s-maxage=86400", expected: 24 * time.Hour, ok: true},
+		{header: "Max-Age=60", expected: time.Minute, ok: true},
+		{header: "no-cache", ok: false},
+		{header: "max-age=soon", ok: false},
+	}
+	for _, c := range cases {
+		header := http.Header{"Cache-Control": {c.header}}
+		actual, ok := maxAge(header)
+		if actual != c.expected || ok != c.ok {
+			t.Errorf("maxAge(%q): expected %v %v, got %v %v", c.header, c.expected, c.ok, actual, ok)
+		}
+	}
+}
+
+func TestCacheManagement(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprintf(w, `{"name":%q}`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(time.Hour, WithBaseURL(server.URL), WithDiskCache(t.TempDir()))
+	defer api.Close()
+	ctx := context.Background()
+	for _, name := range []string{"1", "10", "2"} {
+		if _, err := api.Pokemon(ctx, name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := api.LocationArea(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := api.CacheEntries(server.URL + "/pokemon/")
+	if err != nil || len(entries) != 3 || !entries[0].InMemory || !entries[0].OnDisk {
+		t.Fatalf("unexpected entries %+v, %v", entries, err)
+	}
+
+	// A response only on disk is still shown, with its validators.
+	api.cache.Clear()
+	entry, body, ok := api.CachedResponse(server.URL + "/pokemon/2")
+	if !ok || entry.InMemory || entry.ETag != `"v1"` || !strings.Contains(string(body), "/pokemon/2") {
+		t.Errorf("unexpected cached response %+v %q", entry, body)
+	}
+
+	// An exact URL only evicts itself, not URLs it is a prefix of.
+	if n, err := api.Evict(server.URL + "/pokemon/1"); n != 1 || err != nil {
+		t.Errorf("expected to evict 1 response, got %d, %v", n, err)
+	}
+	if n, err := api.Evict(server.URL + "/pokemon/"); n != 2 || err != nil {
+		t.Errorf("expected to evict 2 responses, got %d, %v", n, err)
+	}
+
+	if err := api.ClearCache(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats, err := api.CacheStats()
+	if err != nil || stats.DiskEntries != 0 || stats.MemoryEntries != 0 || stats.Misses != 4 {
+		t.Errorf("unexpected stats %+v, %v", stats, err)
+	}
+}
+
+func TestObjectCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, `{"id":25,"name":"pikachu","base_experience":112}`)
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(time.Hour, WithBaseURL(server.URL))
+	defer api.Close()
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		pokemon, err := api.Pokemon(ctx, "25")
+		if err != nil || pokemon.Name != "pikachu" {
+			t.Fatalf("unexpected result %+v, %v", pokemon, err)
+		}
+	}
+	// The same response decoded into another type is kept separately.
+	summary, err := ResolveAs[NamedAPIResource](ctx, api, NamedAPIResource{Name: "pikachu", URL: server.URL + "/pokemon/25"})
+	if err != nil || summary.Name != "pikachu" {
+		t.Fatalf("unexpected result %+v, %v", summary, err)
+	}
+	if n := api.cache.Stats().Attached; n != 2 {
+		t.Errorf("expected 2 cached objects, got %d", n)
+	}
+	if m := api.Metrics(); requests.Load() != 1 || m.Hits != 3 || m.Misses != 1 {
+		t.Errorf("expected 1 request and 3 hits, got %d and %+v", requests.Load(), m)
+	}
+
+	// Objects go with the response they were decoded from.
+	if _, err := api.Evict(server.URL + "/pokemon/25"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := api.cache.Stats().Attached; n != 0 {
+		t.Errorf("expected no cached objects after eviction, got %d", n)
+	}
+	if _, err := api.Pokemon(ctx, "25"); err != nil || requests.Load() != 2 {
+		t.Errorf("expected the evicted pokemon to be fetched again, got %d requests, %v", requests.Load(), err)
+	}
+}
+
+// BenchmarkPokemonHit compares a cache hit that decodes the cached JSON,
+// as every hit did before decoded values were cached, with one that
+// returns the cached value.
+func BenchmarkPokemonHit(b *testing.B) {
+	var body strings.Builder
+	body.WriteString(`{"id":25,"name":"pikachu","base_experience":112,"moves":[`)
+	for move := 0; move < 100; move++ {
+		if move > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprintf(&body, `{"move":{"name":"move-%d","url":"https://pokeapi.co/api/v2/move/%d/"},`+
+			`"version_group_details":[{"level_learned_at":%d,"move_learn_method":{"name":"level-up",`+
+			`"url":"https://pokeapi.co/api/v2/move-learn-method/1/"}}]}`, move, move, move)
+	}
+	body.WriteString(`],"types":[{"slot":1,"type":{"name":"electric","url":"https://pokeapi.co/api/v2/type/13/"}}]}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body.String())
+	}))
+	defer server.Close()
+
+	api := NewPokeAPIWrapper(time.Hour, WithBaseURL(server.URL))
+	defer api.Close()
+	ctx := context.Background()
+	fullURL := server.URL + "/pokemon/25"
+	if _, err := api.Pokemon(ctx, "25"); err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.Run("decode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			data, err := api.getBytesFromURL(ctx, fullURL)
+			if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+			var pokemon Pokemon
+			if err := json.Unmarshal(data, &pokemon); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+	b.Run("object", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := getStructFromURL[Pokemon](ctx, api, fullURL); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+}
+
+func TestSpriteURL(t *testing.T) {
+	var pokemon Pokemon
+	err := json.Unmarshal([]byte(`{"sprites":{
+		"front_default":"front.png","back_shiny":"back-shiny.png",
+		"other":{"official-artwork":{"front_default":"artwork.png"}},
+		"versions":{"generation-i":{"red-blue":{"back_default":"red-blue-back.png"}}}
+	}}`), &pokemon)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := []struct {
+		opts     SpriteOptions
+		expected string
+	}{
+		{opts: SpriteOptions{}, expected: "front.png"},
+		{opts: SpriteOptions{Back: true, Shiny: true}, expected: "back-shiny.png"},
+		{opts: SpriteOptions{Version: "official-artwork"}, expected: "artwork.png"},
+		{opts: SpriteOptions{Version: "Red Blue", Back: true}, expected: "red-blue-back.png"},
+		// Red and Blue had no shiny sprites.
+		{opts: SpriteOptions{Version: "red-blue", Shiny: true}, expected: ""},
+	}
+	for _, c := range cases {
+		actual, err := pokemon.SpriteURL(c.opts)
+		if err != nil || actual != c.expected {
+			t.Errorf("SpriteURL(%+v): expected %q, got %q, %v", c.opts, c.expected, actual, err)
+		}
+	}
+	if _, err := pokemon.SpriteURL(SpriteOptions{Version: "pokemon-snap"}); err == nil {
+		t.Errorf("expected an error for an unknown version")
+	}
+}
+
+// typeChartServer serves a few types with their real damage relations,
+// plus "unknown", which has none.
+func typeChartServer() *httptest.Server {
+	types := map[string]string{
+		"electric": `{"double_damage_to":[{"name":"water"},{"name":"flying"}],"no_damage_to":[{"name":"ground"}],"double_damage_from":[{"name":"ground"}],"half_damage_from":[{"name":"electric"},{"name":"flying"}]}`,
+		"water":    `{"double_damage_to":[{"name":"ground"}],"half_damage_to":[{"name":"water"}],"double_damage_from":[{"name":"electric"}],"half_damage_from":[{"name":"water"}]}`,
+		"flying":   `{"half_damage_to":[{"name":"electric"}],"double_damage_from":[{"name":"electric"}],"no_damage_from":[{"name":"ground"}]}`,
+		"ground":   `{"double_damage_to":[{"name":"electric"}],"no_damage_to":[{"name":"flying"}],"double_damage_from":[{"name":"water"}],"no_damage_from":[{"name":"electric"}]}`,
+		"unknown":  `{}`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/type" {
+			var results []string
+			for name := range types {
+				results = append(results, fmt.Sprintf(`{"name":%q,"url":"/type/%s/"}`, name, name))
+			}
+			fmt.Fprintf(w, `{"count":%d,"results":[%s]}`, len(results), strings.Join(results, ","))
+			return
+		}
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/type/"), "/")
+		relations, ok := types[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"name":%q,"damage_relations":%s}`, name, relations)
+	}))
+}
+
+func TestTypeChart(t *testing.T) {
+	server := typeChartServer()
+	defer server.Close()
+	api := NewPokeAPIWrapper(5*time.Second, WithBaseURL(server.URL))
+	defer api.Close()
+
+	chart, err := api.TypeChart(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := chart.Names(); !reflect.DeepEqual(names, []string{"electric", "flying", "ground", "water"}) {
+		t.Errorf("unexpected types %v", names)
+	}
+	cases := []struct {
+		attacking string
+		defending []string
+		expected  float64
+	}{
+		{attacking: "electric", defending: []string{"water", "flying"}, expected: 4},
+		{attacking: "electric", defending: []string{"ground", "water"}, expected: 0},
+		{attacking: "water", defending: []string{"water"}, expected: 0.5},
+		{attacking: "flying", defending: []string{"water"}, expected: 1},
+		{attacking: "fire", defending: []string{"water"}, expected: 1},
+	}
+	for _, c := range cases {
+		if actual := chart.Effectiveness(c.attacking, c.defending); actual != c.expected {
+			t.Errorf("Effectiveness(%s, %v): expected %v, got %v", c.attacking, c.defending, c.expected, actual)
+		}
+	}
+	if defenses := chart.Defenses([]string{"flying"}); defenses["electric"] != 2 || defenses["ground"] != 0 {
+		t.Errorf("unexpected defenses %v", defenses)
+	}
+}
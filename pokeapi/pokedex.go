@@ -0,0 +1,23 @@
+package pokeapi
+
+import "context"
+
+// Pokedex is a list of species numbered as in a region's games, or the
+// national dex of every species.
+type Pokedex struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	IsMainSeries bool   `json:"is_main_series"`
+	// Region is empty for the national dex.
+	Region         NamedAPIResource `json:"region"`
+	PokemonEntries []struct {
+		EntryNumber    int              `json:"entry_number"`
+		PokemonSpecies NamedAPIResource `json:"pokemon_species"`
+	} `json:"pokemon_entries"`
+}
+
+// Pokedex fetches a Pokedex by name, such as "kanto", or ID. A
+// *NotFoundError with suggestions is returned for unknown names.
+func (p *PokeAPIWrapper) Pokedex(ctx context.Context, nameOrID string) (Pokedex, error) {
+	return lookup[Pokedex](ctx, p, KindPokedex, nameOrID)
+}
@@ -0,0 +1,100 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultWorkers is the number of concurrent requests ResolveAll makes when
+// not told otherwise.
+const DefaultWorkers = 8
+
+// ResolveAs follows r and decodes the resource it links to into a T, e.g.
+//
+//	pokemon, err := pokeapi.ResolveAs[pokeapi.Pokemon](ctx, api, encounter.Pokemon)
+//
+// Unlike Resolve, the decoded T is cached along with the response.
+func ResolveAs[T any](ctx context.Context, p *PokeAPIWrapper, r NamedAPIResource) (T, error) {
+	fullURL, err := p.resolveURL(r.URL)
+	if err != nil {
+		var noop T
+		return noop, fmt.Errorf("invalid URL for %s: %w", r.Name, err)
+	}
+	result, err := getStructFromURL[T](ctx, p, fullURL)
+	if err != nil {
+		var noop T
+		return noop, fmt.Errorf("failed to resolve %s: %w", r.Name, err)
+	}
+	return result, nil
+}
+
+// ResolveAll follows every link in resources with at most workers requests
+// in flight, DefaultWorkers if workers is not positive. Links to the same
+// URL are fetched once. The results are in the order of resources; a link
+// that couldn't be resolved leaves a zero T, and its error is included in
+// the joined error returned.
+func ResolveAll[T any](ctx context.Context, p *PokeAPIWrapper, resources []NamedAPIResource, workers int) ([]T, error) {
+	results := make([]T, len(resources))
+	var errs []error
+	var mux sync.Mutex
+	resolveEach(ctx, p, resources, workers, func(indexes []int, result T, err error) {
+		mux.Lock()
+		defer mux.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, i := range indexes {
+			results[i] = result
+		}
+	})
+	return results, errors.Join(errs...)
+}
+
+// resolveEach resolves every distinct URL in resources with a pool of
+// workers and calls done, possibly concurrently, with the indexes of the
+// resources sharing each URL. Once ctx is done, the remaining URLs are
+// reported with its error without being fetched.
+func resolveEach[T any](ctx context.Context, p *PokeAPIWrapper, resources []NamedAPIResource, workers int, done func(indexes []int, result T, err error)) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	indexes := make(map[string][]int)
+	var unique []NamedAPIResource
+	for i, r := range resources {
+		if _, ok := indexes[r.URL]; !ok {
+			unique = append(unique, r)
+		}
+		indexes[r.URL] = append(indexes[r.URL], i)
+	}
+
+	jobs := make(chan NamedAPIResource)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(unique)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				result, err := ResolveAs[T](ctx, p, r)
+				done(indexes[r.URL], result, err)
+			}
+		}()
+	}
+
+feed:
+	for j, r := range unique {
+		select {
+		case jobs <- r:
+		case <-ctx.Done():
+			var noop T
+			for _, r := range unique[j:] {
+				done(indexes[r.URL], noop, fmt.Errorf("failed to resolve %s: %w", r.Name, ctx.Err()))
+			}
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
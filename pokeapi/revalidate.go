@@ -0,0 +1,105 @@
+package pokeapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cachedResponseMagic starts every cachedResponse on disk, so entries in
+// any other format are ignored.
+const cachedResponseMagic = "pokeapi-cache/1"
+
+// cachedResponse is a response body as kept in the disk cache, along with
+// the validators to revalidate it once it's no longer fresh.
+type cachedResponse struct {
+	ETag         string
+	LastModified string
+	Expires      time.Time
+	Body         []byte
+}
+
+func (r *cachedResponse) fresh(now time.Time) bool {
+	return now.Before(r.Expires)
+}
+
+// validated reports whether r has validators to revalidate it with.
+func (r *cachedResponse) validated() bool {
+	return r.ETag != "" || r.LastModified != ""
+}
+
+// setConditional makes req fetch the resource only if it changed since r.
+func (r *cachedResponse) setConditional(req *http.Request) {
+	if r.ETag != "" {
+		req.Header.Set("If-None-Match", r.ETag)
+	}
+	if r.LastModified != "" {
+		req.Header.Set("If-Modified-Since", r.LastModified)
+	}
+}
+
+// MarshalBinary encodes r as a header of validators followed by the body.
+func (r *cachedResponse) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, cachedResponseMagic)
+	fmt.Fprintf(&buf, "etag: %s\n", r.ETag)
+	fmt.Fprintf(&buf, "last-modified: %s\n", r.LastModified)
+	fmt.Fprintf(&buf, "expires: %d\n", r.Expires.Unix())
+	fmt.Fprintln(&buf)
+	buf.Write(r.Body)
+	return buf.Bytes(), nil
+}
+
+func (r *cachedResponse) UnmarshalBinary(data []byte) error {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	magic, err := reader.ReadString('\n')
+	if err != nil || strings.TrimSpace(magic) != cachedResponseMagic {
+		return fmt.Errorf("not a cached response")
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("truncated cached response")
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		switch name {
+		case "etag":
+			r.ETag = value
+		case "last-modified":
+			r.LastModified = value
+		case "expires":
+			unix, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid expiry in cached response: %v", err)
+			}
+			r.Expires = time.Unix(unix, 0)
+		}
+	}
+	r.Body, err = io.ReadAll(reader)
+	return err
+}
+
+// maxAge returns the max-age of a response's Cache-Control header, if any.
+func maxAge(header http.Header) (time.Duration, bool) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil || seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
@@ -0,0 +1,121 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SpriteOptions picks one of a Pokemon's sprites.
+type SpriteOptions struct {
+	Back  bool
+	Shiny bool
+	// Version is a game version such as "red-blue" or "crystal", or one of
+	// the other sprite sets such as "home" or "official-artwork". Empty
+	// picks the default sprites.
+	Version string
+}
+
+// SpriteURL returns the URL of the sprite chosen by opts, or "" if the
+// Pokemon has no such sprite. It fails if opts.Version is not one of
+// SpriteVersions.
+func (p Pokemon) SpriteURL(opts SpriteOptions) (string, error) {
+	set, ok := spriteSet(reflect.ValueOf(p.Sprites), opts.Version)
+	if !ok {
+		return "", fmt.Errorf("unknown sprite version %q, expected one of %s",
+			opts.Version, strings.Join(SpriteVersions(), ", "))
+	}
+	side, variant := "front", "default"
+	if opts.Back {
+		side = "back"
+	}
+	if opts.Shiny {
+		variant = "shiny"
+	}
+	field, ok := jsonField(set, side+"-"+variant)
+	if !ok || field.Kind() != reflect.String {
+		return "", nil
+	}
+	return field.String(), nil
+}
+
+// SpriteVersions lists the names SpriteOptions.Version accepts.
+func SpriteVersions() []string {
+	var versions []string
+	for _, set := range spriteSets(reflect.ValueOf(Pokemon{}.Sprites)) {
+		versions = append(versions, set.name)
+	}
+	return versions
+}
+
+// Sprite downloads the image at url, e.g. one returned by SpriteURL,
+// through the client's caches. Offline, it fails with ErrNotInBundle.
+func (p *PokeAPIWrapper) Sprite(ctx context.Context, url string) ([]byte, error) {
+	if p.offline {
+		return nil, fmt.Errorf("sprite %w", ErrNotInBundle)
+	}
+	data, err := p.getBytesFromURL(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sprite: %w", err)
+	}
+	return data, nil
+}
+
+type namedSpriteSet struct {
+	name  string
+	value reflect.Value
+}
+
+// spriteSets lists the sets of sprites in sprites.other and, by game
+// version, in sprites.versions. Where generations share a name, such as
+// "icons", the earliest is listed.
+func spriteSets(sprites reflect.Value) []namedSpriteSet {
+	var sets []namedSpriteSet
+	seen := make(map[string]bool)
+	add := func(group reflect.Value) {
+		for i := 0; i < group.NumField(); i++ {
+			name := jsonName(group.Type().Field(i))
+			if !seen[name] {
+				seen[name] = true
+				sets = append(sets, namedSpriteSet{name, group.Field(i)})
+			}
+		}
+	}
+	other, _ := jsonField(sprites, "other")
+	add(other)
+	versions, _ := jsonField(sprites, "versions")
+	for i := 0; i < versions.NumField(); i++ {
+		add(versions.Field(i))
+	}
+	return sets
+}
+
+func spriteSet(sprites reflect.Value, version string) (reflect.Value, bool) {
+	if version == "" {
+		return sprites, true
+	}
+	for _, set := range spriteSets(sprites) {
+		if set.name == NormalizeName(version) {
+			return set.value, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// jsonField returns the field of struct v whose jsonName is name.
+func jsonField(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// jsonName returns the JSON key of a field, with underscores replaced by
+// dashes as in resource names.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return strings.ReplaceAll(name, "_", "-")
+}
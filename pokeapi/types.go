@@ -0,0 +1,118 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Type is a Pokemon or move type along with how much damage moves of each
+// type do against it, and moves of it against the others.
+type Type struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	DamageRelations struct {
+		DoubleDamageFrom []NamedAPIResource `json:"double_damage_from"`
+		DoubleDamageTo   []NamedAPIResource `json:"double_damage_to"`
+		HalfDamageFrom   []NamedAPIResource `json:"half_damage_from"`
+		HalfDamageTo     []NamedAPIResource `json:"half_damage_to"`
+		NoDamageFrom     []NamedAPIResource `json:"no_damage_from"`
+		NoDamageTo       []NamedAPIResource `json:"no_damage_to"`
+	} `json:"damage_relations"`
+	// Pokemon lists the Pokemon of this type, including alternate forms.
+	Pokemon []struct {
+		Slot    int              `json:"slot"`
+		Pokemon NamedAPIResource `json:"pokemon"`
+	} `json:"pokemon"`
+}
+
+// Type fetches a type by name or ID. A *NotFoundError with suggestions is
+// returned for unknown names.
+func (p *PokeAPIWrapper) Type(ctx context.Context, nameOrID string) (Type, error) {
+	return lookup[Type](ctx, p, KindType, nameOrID)
+}
+
+// Multiplier returns how much damage moves of type t do against a Pokemon
+// of the given type: 0, 0.5, 1 or 2.
+func (t Type) Multiplier(defending string) float64 {
+	relations := t.DamageRelations
+	switch {
+	case containsName(relations.NoDamageTo, defending):
+		return 0
+	case containsName(relations.HalfDamageTo, defending):
+		return 0.5
+	case containsName(relations.DoubleDamageTo, defending):
+		return 2
+	}
+	return 1
+}
+
+func containsName(resources []NamedAPIResource, name string) bool {
+	for _, r := range resources {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeChart holds the types that take part in battles by name.
+type TypeChart map[string]Type
+
+// TypeChart fetches every type that takes part in battles, leaving out
+// ones such as "unknown" that no move or Pokemon deals damage with.
+func (p *PokeAPIWrapper) TypeChart(ctx context.Context) (TypeChart, error) {
+	result, err := Crawl[Type](ctx, p, KindType, CrawlOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the type chart: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		e := result.Errors[0]
+		return nil, fmt.Errorf("failed to get the type chart: type %s: %w", e.Resource.Name, e.Err)
+	}
+	chart := make(TypeChart)
+	for _, t := range result.Items {
+		relations := t.DamageRelations
+		if len(relations.DoubleDamageTo)+len(relations.HalfDamageTo)+len(relations.NoDamageTo)+
+			len(relations.DoubleDamageFrom)+len(relations.HalfDamageFrom)+len(relations.NoDamageFrom) > 0 {
+			chart[t.Name] = t
+		}
+	}
+	return chart, nil
+}
+
+// Names returns the names of the types in the chart, sorted.
+func (c TypeChart) Names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Effectiveness returns how much damage a move of the attacking type does
+// against a Pokemon of the defending types, e.g. 4 for an electric move
+// against a water and flying type. Unknown attacking types do normal
+// damage.
+func (c TypeChart) Effectiveness(attacking string, defending []string) float64 {
+	t, ok := c[attacking]
+	if !ok {
+		return 1
+	}
+	multiplier := 1.0
+	for _, d := range defending {
+		multiplier *= t.Multiplier(d)
+	}
+	return multiplier
+}
+
+// Defenses returns the Effectiveness of every type in the chart against a
+// Pokemon of the defending types.
+func (c TypeChart) Defenses(defending []string) map[string]float64 {
+	defenses := make(map[string]float64, len(c))
+	for name := range c {
+		defenses[name] = c.Effectiveness(name, defending)
+	}
+	return defenses
+}
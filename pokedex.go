@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+// pokedexPageSize is how many rows a page of the pokedex listing holds.
+const pokedexPageSize = 20
+
+// unknownGeneration labels the group of Pokemon whose generation isn't
+// known; it sorts after every generation number.
+const unknownGeneration = "?"
+
+// completion counts the species of a Pokedex or generation seen and
+// caught.
+type completion struct {
+	name                string
+	seen, caught, total int
+}
+
+func newCompletion(name string, species []string, seen, caught map[string]bool) completion {
+	c := completion{name: name, total: len(species)}
+	for _, name := range species {
+		if seen[name] {
+			c.seen++
+		}
+		if caught[name] {
+			c.caught++
+		}
+	}
+	return c
+}
+
+// percent is the share of species caught.
+func (c completion) percent() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	return float64(c.caught) * 100 / float64(c.total)
+}
+
+func (c completion) String() string {
+	return fmt.Sprintf("seen %d/%d, caught %d/%d (%.1f%%)", c.seen, c.total, c.caught, c.total, c.percent())
+}
+
+func dexSpecies(dex pokeapi.Pokedex) []string {
+	species := make([]string, len(dex.PokemonEntries))
+	for i, entry := range dex.PokemonEntries {
+		species[i] = entry.PokemonSpecies.Name
+	}
+	return species
+}
+
+func completePokedexes(s *session) []string {
+	names, err := s.api.Names(s.ctx, pokeapi.KindPokedex)
+	if err != nil {
+		return nil
+	}
+	return names
+}
+
+func commandPokedex(s *session, in *invocation) error {
+	if in.bool("progress") {
+		return pokedexProgress(s, in)
+	}
+	if in.has("dex") {
+		return pokedexEntries(s, in, in.str("dex"))
+	}
+	return pokedexList(s, in)
+}
+
+// pokedexRow is a line of the pokedex listing: a caught Pokemon and the
+// group it is listed in, if grouped.
+type pokedexRow struct {
+	group string
+	r     record
+}
+
+// pokedexList lists the Pokemon caught a page at a time, filtered by a
+// search query, sorted, and grouped by type or generation.
+func pokedexList(s *session, in *invocation) error {
+	var query []string
+	if in.has("filter") {
+		query = strings.Fields(in.str("filter"))
+	}
+	filter, err := parseQuery(query)
+	if err != nil {
+		return err
+	}
+	field, reverse := sortOrder(in)
+	var group string
+	if in.has("group") {
+		group = strings.ToLower(in.str("group"))
+		if group == "gen" {
+			group = "generation"
+		}
+		if group != "type" && group != "generation" {
+			return fmt.Errorf("invalid group %q, expected type or generation", in.str("group"))
+		}
+	}
+	page := 1
+	if in.has("page") {
+		page = in.int("page")
+	}
+	if page < 1 {
+		return fmt.Errorf("invalid page %d", page)
+	}
+
+	var generations generationIndex
+	if group == "generation" || usesGeneration(field, query) {
+		if generations, _, err = s.speciesGenerations(in); err != nil {
+			return err
+		}
+	}
+	var matches []record
+	for _, name := range sortedKeys(s.game.caught) {
+		pokemon := s.game.caught[name]
+		r := generations.annotate(pokemonRecord(pokemon), pokemon)
+		if order, ok := s.game.caughtOrder[name]; ok {
+			r.set("caught", strconv.Itoa(order))
+		}
+		if filter(r) {
+			matches = append(matches, r)
+		}
+	}
+	sortRecords(matches, field, reverse)
+	for _, match := range matches {
+		in.emit(match)
+	}
+
+	rows := groupRows(matches, group)
+	pages := max((len(rows)+pokedexPageSize-1)/pokedexPageSize, 1)
+	if page > pages {
+		return fmt.Errorf("page %d of %d doesn't exist", page, pages)
+	}
+	start := (page - 1) * pokedexPageSize
+	end := min(start+pokedexPageSize, len(rows))
+	if len(rows) > 0 {
+		r := s.renderer(in)
+		r.Table(in.out, pokedexTable(r, rows[start:end], group))
+	}
+
+	if len(query) > 0 {
+		fmt.Fprintf(in.out, "%d of %d caught Pokemon match.\n", len(matches), len(s.game.caught))
+	}
+	if pages > 1 {
+		fmt.Fprintf(in.out, "Page %d of %d.", page, pages)
+		if page < pages {
+			fmt.Fprintf(in.out, " See the next with --page %d.", page+1)
+		}
+		fmt.Fprintln(in.out, "")
+	}
+	fmt.Fprintf(in.out, "Seen %d species, caught %d.\n", len(s.game.seen), len(s.game.caughtSpecies()))
+	return nil
+}
+
+// usesGeneration reports whether sorting by field or filtering by query
+// needs the gen or region fields.
+func usesGeneration(field string, query []string) bool {
+	fields := []string{field}
+	for _, term := range query {
+		term = strings.TrimPrefix(term, "!")
+		if i := strings.IndexAny(term, ":=!<>~"); i > 0 {
+			fields = append(fields, queryField(term[:i]))
+		}
+	}
+	for _, field := range fields {
+		if field == "gen" || field == "region" {
+			return true
+		}
+	}
+	return false
+}
+
+// groupRows returns a row for each record in each of its groups, ordered
+// by group and then as the records are. A Pokemon of two types is listed
+// under both.
+func groupRows(records []record, group string) []pokedexRow {
+	var rows []pokedexRow
+	for _, r := range records {
+		switch group {
+		case "type":
+			for _, typ := range r.values("type") {
+				rows = append(rows, pokedexRow{typ, r})
+			}
+		case "generation":
+			gen := firstValue(r, "gen")
+			if gen == "" {
+				gen = unknownGeneration
+			}
+			rows = append(rows, pokedexRow{gen, r})
+		default:
+			rows = append(rows, pokedexRow{r: r})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return compareValues(rows[i].group, rows[j].group) < 0
+	})
+	return rows
+}
+
+// pokedexTable lays out rows with the group named only on its first row.
+func pokedexTable(r render.Renderer, rows []pokedexRow, group string) render.Table {
+	table := render.Table{
+		Header:     []string{"#", "pokemon", "types", "bst"},
+		RightAlign: []bool{true, false, false, true},
+	}
+	if group != "" {
+		table.Header = append([]string{group}, table.Header...)
+		table.RightAlign = append([]bool{false}, table.RightAlign...)
+	}
+	for i, row := range rows {
+		cells := []string{
+			firstValue(row.r, "id"), row.r.name, paintTypes(r, row.r.values("type")), firstValue(row.r, "bst"),
+		}
+		if group != "" {
+			label := ""
+			if i == 0 || row.group != rows[i-1].group {
+				label = groupLabel(r, row, group)
+			}
+			cells = append([]string{label}, cells...)
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+	return table
+}
+
+func groupLabel(r render.Renderer, row pokedexRow, group string) string {
+	if group == "type" {
+		return paintTypes(r, []string{row.group})
+	}
+	if region := firstValue(row.r, "region"); region != "" {
+		return row.group + " " + region
+	}
+	return row.group
+}
+
+// pokedexEntries lists a Pokedex by entry number, showing the species not
+// yet seen as gaps.
+func pokedexEntries(s *session, in *invocation, name string) error {
+	dex, err := s.api.Pokedex(s.ctx, name)
+	if err != nil {
+		return err
+	}
+	caught := s.game.caughtSpecies()
+	fmt.Fprintf(in.out, "%s pokedex: %v\n", dex.Name, newCompletion(dex.Name, dexSpecies(dex), s.game.seen, caught))
+
+	table := render.Table{
+		Header:     []string{"#", "pokemon", ""},
+		RightAlign: []bool{true, false, false},
+	}
+	r := s.renderer(in)
+	for _, entry := range dex.PokemonEntries {
+		species := entry.PokemonSpecies.Name
+		row := []string{strconv.Itoa(entry.EntryNumber), species, ""}
+		switch {
+		case caught[species]:
+			row[2] = r.Paint(render.Green, "caught")
+		case s.game.seen[species]:
+			row[2] = "seen"
+		default:
+			row[1] = "---"
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	r.Table(in.out, table)
+	return nil
+}
+
+// pokedexProgress shows how complete every regional Pokedex and generation
+// is. Either may be missing, e.g. from an offline bundle.
+func pokedexProgress(s *session, in *invocation) error {
+	caught := s.game.caughtSpecies()
+	table := render.Table{
+		Header:     []string{"pokedex", "seen", "caught", "total", "complete"},
+		RightAlign: []bool{false, true, true, true, true},
+	}
+	addRow := func(c completion) {
+		table.Rows = append(table.Rows, []string{
+			c.name, strconv.Itoa(c.seen), strconv.Itoa(c.caught), strconv.Itoa(c.total),
+			fmt.Sprintf("%.1f%%", c.percent()),
+		})
+	}
+
+	dexes, err := pokeapi.Crawl[pokeapi.Pokedex](s.ctx, s.api, pokeapi.KindPokedex, pokeapi.CrawlOptions{})
+	if err != nil {
+		if s.ctx.Err() != nil {
+			return err
+		}
+		fmt.Fprintf(in.out, "Regional pokedexes are unavailable: %v\n", err)
+	}
+	for _, dex := range dexes.Items {
+		if dex.IsMainSeries && len(dex.PokemonEntries) > 0 {
+			addRow(newCompletion(dex.Name, dexSpecies(dex), s.game.seen, caught))
+		}
+	}
+	generations, err := pokeapi.Crawl[pokeapi.Generation](s.ctx, s.api, pokeapi.KindGeneration, pokeapi.CrawlOptions{})
+	if err != nil {
+		if s.ctx.Err() != nil {
+			return err
+		}
+		fmt.Fprintf(in.out, "Generations are unavailable: %v\n", err)
+	}
+	for _, generation := range generations.Items {
+		species := make([]string, len(generation.PokemonSpecies))
+		for i, resource := range generation.PokemonSpecies {
+			species[i] = resource.Name
+		}
+		addRow(newCompletion(generation.Name, species, s.game.seen, caught))
+	}
+
+	if len(table.Rows) > 0 {
+		s.renderer(in).Table(in.out, table)
+	}
+	return nil
+}
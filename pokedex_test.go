@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+func TestCompletion(t *testing.T) {
+	g := newGame()
+	g.see("pidgey")
+	g.see("pikachu")
+	g.catch(testPokemon(t, `{"name":"pikachu","species":{"name":"pikachu"}}`))
+	g.catch(testPokemon(t, `{"name":"deoxys-normal","species":{"name":"deoxys"}}`))
+	g.catch(pokeapi.Pokemon{Name: "mew"})
+
+	dex := []string{"pikachu", "pidgey", "rattata", "mew"}
+	c := newCompletion("kanto", dex, g.seen, g.caughtSpecies())
+	if expected := (completion{name: "kanto", seen: 3, caught: 2, total: 4}); c != expected {
+		t.Errorf("expected %+v, got %+v", expected, c)
+	}
+	if expected := "seen 3/4, caught 2/4 (50.0%)"; c.String() != expected {
+		t.Errorf("expected %q, got %q", expected, c.String())
+	}
+	// Caught Pokemon count by species, not by form.
+	if !g.seen["deoxys"] || g.seen["deoxys-normal"] {
+		t.Errorf("expected deoxys to be seen by species, got %v", g.seen)
+	}
+	if percent := (completion{}).percent(); percent != 0 {
+		t.Errorf("expected an empty pokedex to be 0%% complete, got %v", percent)
+	}
+}
+
+func TestGroupRows(t *testing.T) {
+	records := []record{
+		newRecord("pikachu").set("type", "electric").set("gen", "1"),
+		newRecord("gyarados").set("type", "water", "flying").set("gen", "1"),
+		newRecord("mudkip").set("type", "water").set("gen", "3"),
+		newRecord("missingno").set("type", "bird"),
+	}
+	cases := []struct {
+		group    string
+		expected []pokedexRow
+	}{
+		{"", []pokedexRow{{"", records[0]}, {"", records[1]}, {"", records[2]}, {"", records[3]}}},
+		{"type", []pokedexRow{
+			{"bird", records[3]}, {"electric", records[0]}, {"flying", records[1]},
+			{"water", records[1]}, {"water", records[2]},
+		}},
+		{"generation", []pokedexRow{{"1", records[0]}, {"1", records[1]}, {"3", records[2]}, {"?", records[3]}}},
+	}
+	for _, c := range cases {
+		if rows := groupRows(records, c.group); !reflect.DeepEqual(rows, c.expected) {
+			t.Errorf("group %q: expected %v, got %v", c.group, c.expected, rows)
+		}
+	}
+
+	for _, c := range []struct {
+		field    string
+		query    []string
+		expected bool
+	}{
+		{"id", nil, false},
+		{"gen", nil, true},
+		{"bst", []string{"type=fire", "chu"}, false},
+		{"bst", []string{"!region:kanto"}, true},
+		{"name", []string{"generation:iv"}, true},
+	} {
+		if uses := usesGeneration(c.field, c.query); uses != c.expected {
+			t.Errorf("%s %v: expected %v, got %v", c.field, c.query, c.expected, uses)
+		}
+	}
+}
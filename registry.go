@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// valueType is the type of a positional argument or flag value.
+type valueType int
+
+const (
+	valueString valueType = iota
+	valueInt
+	valueBool
+)
+
+// argSpec declares a positional argument of a command.
+type argSpec struct {
+	name     string
+	typ      valueType
+	optional bool
+	// rest makes the argument take all remaining words joined by spaces,
+	// so multi-word names like "mr. mime" work unquoted.
+	rest bool
+	// variadic makes the argument take all remaining words as a list.
+	variadic bool
+	// pipe lets the argument be fed from a pipe: when it is missing and the
+	// command reads piped records, the command runs once per record with
+	// the record's name as the argument.
+	pipe     bool
+	complete func(s *session) []string
+}
+
+// flagSpec declares a flag of a command. Bool flags take no value.
+type flagSpec struct {
+	name  string
+	short string
+	typ   valueType
+	usage string
+}
+
+// command declares a REPL command. Help, argument validation and Tab
+// completion are all generated from these declarations.
+type command struct {
+	name        string
+	aliases     []string
+	category    string
+	summary     string
+	description string
+	args        []argSpec
+	flags       []flagSpec
+	// rawArgs passes the rest of the input line to the handler untokenized
+	// as its first argument, for commands whose arguments are themselves
+	// command syntax.
+	rawArgs bool
+	handler func(s *session, in *invocation) error
+}
+
+// invocation is a command bound to the arguments it was called with.
+type invocation struct {
+	command *command
+	out     io.Writer
+	args    map[string]any
+	flags   map[string]any
+
+	// piped is set when the command is on the right of a "|"; input holds
+	// the records the previous command emitted.
+	piped bool
+	input []record
+	// records collects what the command emits for the next one in a pipe.
+	records []record
+}
+
+// emit passes r on to the next command in a pipe.
+func (in *invocation) emit(r record) {
+	in.records = append(in.records, r)
+}
+
+func (c *command) pipeArg() (argSpec, bool) {
+	for _, arg := range c.args {
+		if arg.pipe {
+			return arg, true
+		}
+	}
+	return argSpec{}, false
+}
+
+func (in *invocation) str(name string) string {
+	switch v := in.value(name).(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, " ")
+	case int:
+		return strconv.Itoa(v)
+	}
+	return ""
+}
+
+func (in *invocation) int(name string) int {
+	v, _ := in.value(name).(int)
+	return v
+}
+
+func (in *invocation) bool(name string) bool {
+	v, _ := in.value(name).(bool)
+	return v
+}
+
+func (in *invocation) list(name string) []string {
+	v, _ := in.value(name).([]string)
+	return v
+}
+
+func (in *invocation) has(name string) bool {
+	return in.value(name) != nil
+}
+
+func (in *invocation) value(name string) any {
+	if v, ok := in.args[name]; ok {
+		return v
+	}
+	return in.flags[name]
+}
+
+// usage renders the command's synopsis, e.g. "history [count]".
+func (c *command) usage() string {
+	parts := []string{c.name}
+	for _, flag := range c.flags {
+		f := "--" + flag.name
+		if flag.short != "" {
+			f = "-" + flag.short + "|" + f
+		}
+		if flag.typ != valueBool {
+			f += " <" + flag.name + ">"
+		}
+		parts = append(parts, "["+f+"]")
+	}
+	for _, arg := range c.args {
+		a := arg.name
+		if arg.rest || arg.variadic {
+			a += "..."
+		}
+		if arg.optional {
+			a = "[" + a + "]"
+		} else {
+			a = "<" + a + ">"
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *command) flag(word string) (flagSpec, bool) {
+	for _, flag := range c.flags {
+		if word == "--"+flag.name || (flag.short != "" && word == "-"+flag.short) {
+			return flag, true
+		}
+	}
+	return flagSpec{}, false
+}
+
+// bind validates words against the command's declared flags and arguments.
+func (c *command) bind(words []string) (*invocation, error) {
+	in := &invocation{
+		command: c,
+		args:    make(map[string]any),
+		flags:   make(map[string]any),
+	}
+
+	var positional []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if word == "--" {
+			positional = append(positional, words[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(word, "-") || word == "-" || isNumber(word) {
+			positional = append(positional, word)
+			continue
+		}
+
+		// --flag=value carries its value in the same word.
+		word, value, hasValue := strings.Cut(word, "=")
+		flag, ok := c.flag(word)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown flag %s", c.name, word)
+		}
+		if flag.typ == valueBool && !hasValue {
+			in.flags[flag.name] = true
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(words) {
+				return nil, fmt.Errorf("%s: flag --%s requires a value", c.name, flag.name)
+			}
+			i++
+			value = words[i]
+		}
+		v, err := parseValue(flag.typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: flag --%s: %v", c.name, flag.name, err)
+		}
+		in.flags[flag.name] = v
+	}
+
+	for _, arg := range c.args {
+		if len(positional) == 0 {
+			if !arg.optional {
+				return nil, fmt.Errorf("%s requires a %s argument (usage: %s)", c.name, arg.name, c.usage())
+			}
+			continue
+		}
+		switch {
+		case arg.rest:
+			in.args[arg.name] = strings.Join(positional, " ")
+			positional = nil
+		case arg.variadic:
+			in.args[arg.name] = positional
+			positional = nil
+		default:
+			v, err := parseValue(arg.typ, positional[0])
+			if err != nil {
+				return nil, fmt.Errorf("%s: argument %s: %v", c.name, arg.name, err)
+			}
+			in.args[arg.name] = v
+			positional = positional[1:]
+		}
+	}
+	if len(positional) > 0 {
+		if len(c.args) == 0 {
+			return nil, fmt.Errorf("%s does not take any arguments", c.name)
+		}
+		return nil, fmt.Errorf("%s: too many arguments (usage: %s)", c.name, c.usage())
+	}
+	return in, nil
+}
+
+func parseValue(typ valueType, word string) (any, error) {
+	switch typ {
+	case valueInt:
+		n, err := strconv.Atoi(word)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", word)
+		}
+		return n, nil
+	case valueBool:
+		b, err := strconv.ParseBool(word)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", word)
+		}
+		return b, nil
+	}
+	return word, nil
+}
+
+func isNumber(word string) bool {
+	_, err := strconv.Atoi(word)
+	return err == nil
+}
+
+// registry holds the declared commands in declaration order, which is also
+// the order help lists them in.
+type registry struct {
+	commands []*command
+	byName   map[string]*command
+}
+
+func newRegistry(commands ...*command) *registry {
+	r := &registry{byName: make(map[string]*command)}
+	for _, c := range commands {
+		r.register(c)
+	}
+	return r
+}
+
+func (r *registry) register(c *command) {
+	for _, name := range append([]string{c.name}, c.aliases...) {
+		if _, ok := r.byName[name]; ok {
+			panic(fmt.Sprintf("command %q registered twice", name))
+		}
+		r.byName[name] = c
+	}
+	r.commands = append(r.commands, c)
+}
+
+func (r *registry) lookup(name string) (*command, bool) {
+	c, ok := r.byName[strings.ToLower(name)]
+	return c, ok
+}
+
+// names returns every command name and alias, sorted.
+func (r *registry) names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// categories returns the command categories in the order they were first
+// declared.
+func (r *registry) categories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, c := range r.commands {
+		if !seen[c.category] {
+			seen[c.category] = true
+			categories = append(categories, c.category)
+		}
+	}
+	return categories
+}
+
+// candidates generates Tab completion candidates from the declarations.
+func (r *registry) candidates(s *session, words []string, argIndex int) []string {
+	if argIndex == 0 {
+		return r.names()
+	}
+	c, ok := r.lookup(words[0])
+	if !ok {
+		return nil
+	}
+
+	if len(words) > 1 {
+		if flag, ok := c.flag(words[len(words)-1]); ok && flag.typ != valueBool {
+			return nil
+		}
+	}
+	position := 0
+	for i := 1; i < len(words); i++ {
+		flag, ok := c.flag(words[i])
+		switch {
+		case ok && flag.typ != valueBool:
+			i++
+		case !ok:
+			position++
+		}
+	}
+
+	for i, arg := range c.args {
+		if i == position || (i < position && (arg.rest || arg.variadic)) {
+			if arg.complete == nil {
+				return nil
+			}
+			return arg.complete(s)
+		}
+	}
+	return nil
+}
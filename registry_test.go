@@ -0,0 +1,116 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testCommand() *command {
+	return &command{
+		name: "test",
+		args: []argSpec{
+			{name: "count", typ: valueInt},
+			{name: "names", variadic: true, optional: true},
+		},
+		flags: []flagSpec{
+			{name: "verbose", short: "v", typ: valueBool},
+			{name: "limit", typ: valueInt},
+		},
+	}
+}
+
+func TestBind(t *testing.T) {
+	in, err := testCommand().bind([]string{"-v", "3", "--limit", "10", "a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in.int("count") != 3 || in.int("limit") != 10 || !in.bool("verbose") {
+		t.Errorf("unexpected values: args %v flags %v", in.args, in.flags)
+	}
+	if !reflect.DeepEqual(in.list("names"), []string{"a", "b"}) {
+		t.Errorf("expected names [a b], got %v", in.list("names"))
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"three"},
+		{"3", "--limit"},
+		{"3", "--limit", "ten"},
+		{"3", "--unknown"},
+	}
+	for _, words := range cases {
+		if _, err := testCommand().bind(words); err == nil {
+			t.Errorf("bind(%v): expected error", words)
+		}
+	}
+
+	noArgs := &command{name: "map"}
+	if _, err := noArgs.bind([]string{"extra"}); err == nil {
+		t.Errorf("expected error for unexpected argument")
+	}
+}
+
+func TestBindRest(t *testing.T) {
+	c := &command{name: "catch", args: []argSpec{{name: "pokemon", rest: true}}}
+	in, err := c.bind([]string{"mr.", "mime"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in.str("pokemon") != "mr. mime" {
+		t.Errorf("expected %q, got %q", "mr. mime", in.str("pokemon"))
+	}
+}
+
+func TestUsage(t *testing.T) {
+	expected := "test [-v|--verbose] [--limit <limit>] <count> [names...]"
+	if actual := testCommand().usage(); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestRegistryCandidates(t *testing.T) {
+	r := newRegistry(
+		testCommand(),
+		&command{
+			name:    "catch",
+			aliases: []string{"c"},
+			args: []argSpec{{name: "pokemon", complete: func(*session) []string {
+				return []string{"pikachu"}
+			}}},
+		},
+	)
+
+	if actual := r.candidates(nil, nil, 0); !reflect.DeepEqual(actual, []string{"c", "catch", "test"}) {
+		t.Errorf("unexpected command candidates %v", actual)
+	}
+	if actual := r.candidates(nil, []string{"c"}, 1); !reflect.DeepEqual(actual, []string{"pikachu"}) {
+		t.Errorf("unexpected argument candidates %v", actual)
+	}
+	if actual := r.candidates(nil, []string{"test", "--limit"}, 2); actual != nil {
+		t.Errorf("expected no candidates for a flag value, got %v", actual)
+	}
+}
+
+func TestCommandRegistryDeclarations(t *testing.T) {
+	r := newCommandRegistry()
+	for _, c := range r.commands {
+		if c.handler == nil || c.summary == "" || c.category == "" {
+			t.Errorf("command %q is missing a handler, summary or category", c.name)
+		}
+	}
+}
+
+func TestBindFlagForms(t *testing.T) {
+	in, err := testCommand().bind([]string{"--limit=5", "--verbose=false", "--", "3", "-x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in.int("limit") != 5 || in.bool("verbose") || in.int("count") != 3 {
+		t.Errorf("unexpected values: args %v flags %v", in.args, in.flags)
+	}
+	if !reflect.DeepEqual(in.list("names"), []string{"-x"}) {
+		t.Errorf("expected names [-x], got %v", in.list("names"))
+	}
+}
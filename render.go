@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/internal/sprite"
+	"golang.org/x/term"
+)
+
+// defaultWidth is assumed when the terminal's width is unknown.
+const defaultWidth = 80
+
+// renderer draws the invocation's output within the terminal's width, in
+// color if it goes to the terminal and colors aren't disabled.
+func (s *session) renderer(in *invocation) render.Renderer {
+	r := render.Renderer{Width: defaultWidth}
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		r.Width = width
+	}
+	if s.terminal != nil && in.out == s.terminal && sprite.DetectMode(os.Getenv) != sprite.ASCII {
+		r.Colors = s.terminal.Escape
+	}
+	return r
+}
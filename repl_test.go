@@ -4,33 +4,54 @@ import (
 	"testing"
 )
 
-func TestCleanInput(t *testing.T) {
+func TestTokenize(t *testing.T) {
 	cases := []struct {
-		input string
+		input    string
 		expected []string
 	}{
 		{
-			input: "  hello world  ",
+			input:    "  hello world  ",
 			expected: []string{"hello", "world"},
 		},
 		{
-			input: "hello world",
+			input:    "hello world",
 			expected: []string{"hello", "world"},
 		},
 		{
-			input: "hello  world",
+			input:    "hello  world",
 			expected: []string{"hello", "world"},
 		},
 		{
-			input: "hello  WORLd ",
-			expected: []string{"hello", "world"},
+			input:    "hello  WORLd ",
+			expected: []string{"hello", "WORLd"},
+		},
+		{
+			input:    `catch "Mr. Mime"`,
+			expected: []string{"catch", "Mr. Mime"},
+		},
+		{
+			input:    `say 'it''s' "a \"quoted\" word" back\ slash`,
+			expected: []string{"say", "its", `a "quoted" word`, "back slash"},
+		},
+		{
+			input:    `pokedex --sort=name -f "type=fire"`,
+			expected: []string{"pokedex", "--sort=name", "-f", "type=fire"},
+		},
+		{
+			input:    `empty ""`,
+			expected: []string{"empty", ""},
 		},
 	}
 
 	for _, c := range cases {
-		actual := cleanInput(c.input)
+		actual, err := tokenize(c.input)
+		if err != nil {
+			t.Errorf("tokenize(%q): unexpected error: %v", c.input, err)
+			continue
+		}
 		if len(actual) != len(c.expected) {
 			t.Errorf("Expected %d words, got %d", len(c.expected), len(actual))
+			continue
 		}
 		for i := range c.expected {
 			word := actual[i]
@@ -40,4 +61,12 @@ func TestCleanInput(t *testing.T) {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	for _, input := range []string{`catch "pikachu`, `catch 'pikachu`, `catch pikachu\`} {
+		if _, err := tokenize(input); err == nil {
+			t.Errorf("tokenize(%q): expected error", input)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+// defaultSearchLimit is how many matches search lists unless told
+// otherwise.
+const defaultSearchLimit = 20
+
+// queryFieldAliases maps alternative spellings of query fields to the
+// record fields they mean.
+var queryFieldAliases = map[string]string{
+	"generation": "gen",
+	"types":      "type",
+	"abilities":  "ability",
+	"exp":        "base_experience",
+}
+
+// romanNumerals lets generations be given as in their names, e.g. gen:iv.
+var romanNumerals = map[string]string{
+	"i": "1", "ii": "2", "iii": "3", "iv": "4", "v": "5",
+	"vi": "6", "vii": "7", "viii": "8", "ix": "9",
+}
+
+// parseQuery parses a search query into a filter matching records that
+// satisfy every term. Terms are:
+//
+//	field:value        the field has the value, e.g. type:water
+//	field:a,b          the field has any of the values
+//	!field:value       the field doesn't have the value
+//	field<op>value     as for filter, e.g. bst>500 or name~chu
+//	word               the name contains word
+func parseQuery(terms []string) (recordFilter, error) {
+	var filters []recordFilter
+	for _, term := range terms {
+		filter, err := parseQueryTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return func(r record) bool {
+		for _, filter := range filters {
+			if !filter(r) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func parseQueryTerm(term string) (recordFilter, error) {
+	body, negate := strings.CutPrefix(term, "!")
+	colon := strings.Index(body, ":")
+	op := strings.IndexAny(body, "=!<>~")
+	switch {
+	case colon > 0 && (op < 0 || colon < op):
+		field := queryField(body[:colon])
+		if field == "" {
+			return nil, fmt.Errorf("invalid search term %q, expected field:value", term)
+		}
+		var filters []recordFilter
+		for _, value := range strings.Split(body[colon+1:], ",") {
+			value = queryValue(field, value)
+			expr := field + "=" + value
+			if negate {
+				expr = field + "!=" + value
+			}
+			filter, err := parseFilter([]string{expr})
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+		return func(r record) bool {
+			// A negated term must hold for every value, a plain one for
+			// any.
+			for _, filter := range filters {
+				if filter(r) != negate {
+					return !negate
+				}
+			}
+			return negate
+		}, nil
+	case negate:
+		return nil, fmt.Errorf("invalid search term %q, only field:value terms can be negated", term)
+	case op > 0:
+		return parseFilter([]string{queryField(body[:op]) + body[op:]})
+	case op == 0 || colon == 0:
+		return nil, fmt.Errorf("invalid search term %q, missing field", term)
+	}
+	return parseFilter([]string{"name~" + pokeapi.NormalizeName(body)})
+}
+
+func queryField(field string) string {
+	field = strings.ToLower(field)
+	if alias, ok := queryFieldAliases[field]; ok {
+		return alias
+	}
+	return field
+}
+
+// queryValue normalizes value for field, e.g. "Swift Swim" to
+// "swift-swim" and generation "iv" to "4".
+func queryValue(field, value string) string {
+	value = pokeapi.NormalizeName(value)
+	if field == "gen" {
+		value = strings.TrimPrefix(value, "generation-")
+		if n, ok := romanNumerals[value]; ok {
+			return n
+		}
+	}
+	return value
+}
+
+func commandSearch(s *session, in *invocation) error {
+	filter, err := parseQuery(in.list("query"))
+	if err != nil {
+		return err
+	}
+	limit := defaultSearchLimit
+	if in.has("limit") {
+		limit = in.int("limit")
+	}
+	if limit < 0 {
+		return fmt.Errorf("invalid limit %d", limit)
+	}
+	index, err := s.searchIndex(in)
+	if err != nil {
+		return err
+	}
+
+	var matches []record
+	for _, r := range index {
+		if filter(r) {
+			matches = append(matches, r)
+		}
+	}
+	field, reverse := sortOrder(in)
+	sortRecords(matches, field, reverse)
+
+	shown := matches
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	r := s.renderer(in)
+	table := render.Table{
+		Header:     []string{"#", "pokemon", "types", "bst", "gen"},
+		RightAlign: []bool{true, false, false, true, true},
+	}
+	for _, match := range shown {
+		table.Rows = append(table.Rows, []string{
+			firstValue(match, "id"), match.name, paintTypes(r, match.values("type")),
+			firstValue(match, "bst"), firstValue(match, "gen"),
+		})
+		in.emit(match)
+	}
+	if len(shown) > 0 {
+		r.Table(in.out, table)
+	}
+	fmt.Fprintf(in.out, "%d of %d matches shown.\n", len(shown), len(matches))
+	return nil
+}
+
+// sortOrder returns the field given by the sort flag, by default id, and
+// whether to sort descending: when reverse is set or the field is prefixed
+// with "-", but not both.
+func sortOrder(in *invocation) (field string, reverse bool) {
+	field, reverse = "id", in.bool("reverse")
+	if in.has("sort") {
+		field = queryField(strings.TrimPrefix(in.str("sort"), "-"))
+		reverse = reverse != strings.HasPrefix(in.str("sort"), "-")
+	}
+	return field, reverse
+}
+
+func firstValue(r record, field string) string {
+	if values := r.values(field); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// searchIndex returns a record for every Pokemon, with the fields of
+// pokemonRecord plus its generation and region. It is built from a crawl
+// of every Pokemon the first time, or when asked to rebuild it; with the
+// responses already cached, that doesn't touch the network. An index
+// missing Pokemon or generations that failed to be fetched is used once but
+// not kept, so the next search tries them again.
+func (s *session) searchIndex(in *invocation) ([]record, error) {
+	if s.index != nil && !in.bool("rebuild") {
+		return s.index, nil
+	}
+	generations, generationsFailed, err := s.speciesGenerations(in)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintln(in.out, "Indexing every Pokemon for search...")
+	pokemons, failed, err := crawl[pokeapi.Pokemon](s, in, pokeapi.KindPokemon, pokeapi.CrawlOptions{
+		Progress: progressPrinter(in.out),
+	}, "search --rebuild")
+	if err != nil {
+		return nil, err
+	}
+	index := make([]record, len(pokemons))
+	for i, pokemon := range pokemons {
+		index[i] = generations.annotate(pokemonRecord(pokemon), pokemon)
+	}
+	if failed == 0 && generationsFailed == 0 {
+		s.index = index
+	}
+	return index, nil
+}
+
+// generationIndex maps species names to the generations introducing them.
+type generationIndex map[string]pokeapi.Generation
+
+// speciesGenerations returns the generation of every species, and the
+// number of generations that couldn't be fetched. Without the generations,
+// e.g. in an offline bundle lacking them, it says so and returns an empty
+// index, so that everything else still works.
+func (s *session) speciesGenerations(in *invocation) (generationIndex, int, error) {
+	generations, err := pokeapi.Crawl[pokeapi.Generation](s.ctx, s.api, pokeapi.KindGeneration, pokeapi.CrawlOptions{})
+	failed := len(generations.Errors)
+	switch {
+	case err != nil && s.ctx.Err() != nil:
+		return nil, 0, err
+	case err != nil:
+		fmt.Fprintf(in.out, "Generations are unavailable: %v\n", err)
+		failed++
+	case failed > 0:
+		fmt.Fprintf(in.out, "%d generations couldn't be fetched, so some Pokemon have no gen or region.\n", failed)
+	}
+	index := make(generationIndex)
+	for _, generation := range generations.Items {
+		for _, species := range generation.PokemonSpecies {
+			index[species.Name] = generation
+		}
+	}
+	return index, failed, nil
+}
+
+// annotate sets the gen and region fields of a Pokemon's record.
+func (g generationIndex) annotate(r record, pokemon pokeapi.Pokemon) record {
+	if generation, ok := g[speciesName(pokemon)]; ok {
+		r.set("gen", strconv.Itoa(generation.ID)).set("region", generation.MainRegion.Name)
+	}
+	return r
+}
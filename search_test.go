@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+	"golang.org/x/term"
+)
+
+func TestParseQuery(t *testing.T) {
+	records := []record{
+		newRecord("gyarados").set("type", "water", "flying").set("bst", "540").set("gen", "1").set("ability", "intimidate", "moxie"),
+		newRecord("empoleon").set("type", "water", "steel").set("bst", "530").set("gen", "4").set("ability", "torrent"),
+		newRecord("floatzel").set("type", "water").set("bst", "495").set("gen", "4").set("ability", "swift-swim"),
+		newRecord("luxray").set("type", "electric").set("bst", "523").set("gen", "4").set("ability", "rivalry"),
+	}
+	cases := []struct {
+		query    []string
+		expected []string
+	}{
+		{query: []string{"type:water", "bst>500", "gen:4"}, expected: []string{"empoleon"}},
+		{query: []string{"generation:IV", "ability:Swift Swim"}, expected: []string{"floatzel"}},
+		{query: []string{"gen:generation-iv", "type:steel,electric"}, expected: []string{"empoleon", "luxray"}},
+		{query: []string{"type:water", "!type:flying,steel"}, expected: []string{"floatzel"}},
+		{query: []string{"ra"}, expected: []string{"gyarados", "luxray"}},
+		{query: nil, expected: []string{"gyarados", "empoleon", "floatzel", "luxray"}},
+	}
+	for _, c := range cases {
+		filter, err := parseQuery(c.query)
+		if err != nil {
+			t.Errorf("parseQuery(%q): unexpected error: %v", c.query, err)
+			continue
+		}
+		var actual []string
+		for _, r := range records {
+			if filter(r) {
+				actual = append(actual, r.name)
+			}
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("parseQuery(%q): expected %v, got %v", c.query, c.expected, actual)
+		}
+	}
+
+	for _, query := range []string{"<500", ":water", "!chu", "bst!500"} {
+		if _, err := parseQuery([]string{query}); err == nil {
+			t.Errorf("parseQuery(%q): expected an error", query)
+		}
+	}
+}
+
+func TestSearchIndexKeptWithoutFailures(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/generation":
+			fmt.Fprint(w, `{"count":1,"results":[{"name":"generation-i","url":"/generation/1/"}]}`)
+		case "/generation/1/":
+			if failing.Load() {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"id":1,"name":"generation-i","main_region":{"name":"kanto"},"pokemon_species":[{"name":"pikachu"}]}`)
+		case "/pokemon":
+			fmt.Fprint(w, `{"count":1,"results":[{"name":"pikachu","url":"/pokemon/25/"}]}`)
+		case "/pokemon/25/":
+			fmt.Fprint(w, `{"id":25,"name":"pikachu","species":{"name":"pikachu"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	api := pokeapi.NewPokeAPIWrapper(time.Minute, pokeapi.WithBaseURL(server.URL))
+	defer api.Close()
+	var out strings.Builder
+	s := &session{
+		ctx:      context.Background(),
+		terminal: term.NewTerminal(&screen{strings.NewReader(""), &out}, ""),
+		api:      api,
+		game:     newGame(),
+		registry: newCommandRegistry(),
+	}
+	s.config, _ = loadConfig("")
+
+	search, _ := s.registry.lookup("search")
+	if _, err := s.dispatch(search, []string{"pikachu"}, nil, false, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.index != nil {
+		t.Errorf("expected no index to be kept while a generation fails, got %v", s.index)
+	}
+
+	failing.Store(false)
+	if _, err := s.dispatch(search, []string{"pikachu"}, nil, false, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.index) != 1 || firstValue(s.index[0], "gen") != "1" {
+		t.Errorf("expected an index of pikachu in gen 1, got %v", s.index)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/sprite"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+// spriteWidth is the most columns a sprite is drawn in; the default 96x96
+// sprites are mostly transparent border and usually fit unscaled.
+const spriteWidth = 48
+
+// spriteFlags choose which sprite, if any, a command draws.
+var spriteFlags = []flagSpec{
+	{name: "shiny", short: "s", typ: valueBool, usage: "show the shiny sprite"},
+	{name: "back", short: "b", typ: valueBool, usage: "show the sprite from behind"},
+	{name: "version", short: "v", typ: valueString, usage: "show the sprite of a game version, e.g. red-blue"},
+	{name: "no-sprite", typ: valueBool, usage: "don't show the sprite"},
+}
+
+// spriteURL returns the URL of the sprite of pokemon chosen by the
+// invocation's sprite flags, or "" if none should be drawn.
+func spriteURL(in *invocation, pokemon pokeapi.Pokemon) (string, error) {
+	if in.bool("no-sprite") {
+		return "", nil
+	}
+	return pokemon.SpriteURL(pokeapi.SpriteOptions{
+		Back:    in.bool("back"),
+		Shiny:   in.bool("shiny"),
+		Version: in.str("version"),
+	})
+}
+
+// drawSprite draws the image at url. A sprite that can't be loaded is
+// reported without failing the command, since it is only decoration.
+func drawSprite(s *session, in *invocation, url string) {
+	if url == "" || in.out == io.Discard {
+		return
+	}
+	data, err := s.api.Sprite(s.ctx, url)
+	if errors.Is(err, pokeapi.ErrNotInBundle) {
+		fmt.Fprintln(in.out, "(sprite not in bundle)")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(in.out, "(no sprite: %v)\n", err)
+		return
+	}
+	img, err := sprite.Decode(data)
+	if err != nil {
+		fmt.Fprintf(in.out, "(no sprite: %v)\n", err)
+		return
+	}
+	fmt.Fprint(in.out, sprite.Render(img, spriteWidth, sprite.DetectMode(os.Getenv)))
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+)
+
+const (
+	// maxTeamSize is the most Pokemon a team can have, as in the games.
+	maxTeamSize = 6
+	// maxSuggestedTypes and maxSuggestedSpecies bound the suggestions of
+	// team analyze.
+	maxSuggestedTypes   = 3
+	maxSuggestedSpecies = 3
+)
+
+var teamSubcommands = []string{"ls", "show", "create", "add", "remove", "delete", "analyze"}
+
+func completeTeamSubcommands(s *session) []string {
+	return teamSubcommands
+}
+
+func completeTeams(s *session) []string {
+	return sortedKeys(s.config.Teams)
+}
+
+func completeTeamMembers(s *session) []string {
+	return append(completeCaughtPokemon(s), completePokemon(s)...)
+}
+
+func commandTeam(s *session, in *invocation) error {
+	subcommand := in.str("subcommand")
+	if subcommand == "ls" {
+		return teamList(s, in)
+	}
+	name := in.str("team")
+	if name == "" {
+		return fmt.Errorf("usage: team %s <team>", subcommand)
+	}
+	members, exists := s.config.Teams[name]
+	switch subcommand {
+	case "create":
+		if exists {
+			return fmt.Errorf("team %q already exists", name)
+		}
+		return teamSet(s, name, nil, in.list("members"))
+	case "delete":
+		if !exists {
+			return fmt.Errorf("no team named %q", name)
+		}
+		delete(s.config.Teams, name)
+		return s.config.save()
+	}
+
+	if !exists {
+		return fmt.Errorf("no team named %q, create it with team create %s", name, name)
+	}
+	switch subcommand {
+	case "show":
+		return teamShow(s, in, name, members)
+	case "add":
+		return teamSet(s, name, members, in.list("members"))
+	case "remove":
+		return teamRemove(s, name, members, in.list("members"))
+	case "analyze":
+		return teamAnalyze(s, in, name, members)
+	}
+	return fmt.Errorf("unknown subcommand %q, expected one of %s",
+		subcommand, strings.Join(teamSubcommands, ", "))
+}
+
+func teamList(s *session, in *invocation) error {
+	if len(s.config.Teams) == 0 {
+		fmt.Fprintln(in.out, "No teams yet; create one with team create <team> [pokemon...]")
+		return nil
+	}
+	for _, name := range sortedKeys(s.config.Teams) {
+		members := s.config.Teams[name]
+		fmt.Fprintf(in.out, "%s (%d/%d): %s\n", name, len(members), maxTeamSize, strings.Join(members, ", "))
+	}
+	return nil
+}
+
+// teamSet saves the team called name with added appended to its members,
+// checking that each is a Pokemon.
+func teamSet(s *session, name string, members, added []string) error {
+	if len(members)+len(added) > maxTeamSize {
+		return fmt.Errorf("a team has at most %d Pokemon, %s would have %d",
+			maxTeamSize, name, len(members)+len(added))
+	}
+	members = append([]string(nil), members...)
+	for _, nameOrID := range added {
+		pokemon, err := teamMember(s, nameOrID)
+		if err != nil {
+			return err
+		}
+		members = append(members, pokemon.Name)
+	}
+	s.config.Teams[name] = members
+	return s.config.save()
+}
+
+func teamRemove(s *session, name string, members, removed []string) error {
+	members = append([]string(nil), members...)
+	for _, nameOrID := range removed {
+		i := slices.Index(members, pokeapi.NormalizeName(nameOrID))
+		if i < 0 {
+			// Not named as on the team, e.g. given by ID: look it up as
+			// teamSet does.
+			pokemon, err := teamMember(s, nameOrID)
+			if err != nil {
+				return err
+			}
+			i = slices.Index(members, pokemon.Name)
+		}
+		if i < 0 {
+			return fmt.Errorf("%s is not on team %s", nameOrID, name)
+		}
+		members = append(members[:i], members[i+1:]...)
+	}
+	s.config.Teams[name] = members
+	return s.config.save()
+}
+
+// teamMember looks up a Pokemon for a team among the caught ones first,
+// then among all species.
+func teamMember(s *session, nameOrID string) (pokeapi.Pokemon, error) {
+	if pokemon, ok := findCaughtPokemon(s.game.caught, nameOrID); ok {
+		return pokemon, nil
+	}
+	pokemon, err := s.api.Pokemon(s.ctx, nameOrID)
+	if err != nil {
+		return pokeapi.Pokemon{}, fmt.Errorf("error getting pokemon: %v", err)
+	}
+	return pokemon, nil
+}
+
+func teamPokemon(s *session, members []string) ([]pokeapi.Pokemon, error) {
+	pokemons := make([]pokeapi.Pokemon, len(members))
+	for i, member := range members {
+		pokemon, err := teamMember(s, member)
+		if err != nil {
+			return nil, err
+		}
+		pokemons[i] = pokemon
+	}
+	return pokemons, nil
+}
+
+func teamShow(s *session, in *invocation, name string, members []string) error {
+	pokemons, err := teamPokemon(s, members)
+	if err != nil {
+		return err
+	}
+	r := s.renderer(in)
+	table := render.Table{
+		Header:     []string{"pokemon", "types", "bst", "caught"},
+		RightAlign: []bool{false, false, true, false},
+	}
+	for _, pokemon := range pokemons {
+		caught := ""
+		if _, ok := s.game.caught[pokemon.Name]; ok {
+			caught = "yes"
+		}
+		table.Rows = append(table.Rows, []string{
+			pokemon.Name, paintTypes(r, pokemon.TypeNames()), fmt.Sprint(pokemon.BaseStatTotal()), caught,
+		})
+		in.emit(pokemonRecord(pokemon))
+	}
+	fmt.Fprintf(in.out, "Team %s (%d/%d):\n", name, len(members), maxTeamSize)
+	r.Table(in.out, table)
+	return nil
+}
+
+func paintTypes(r render.Renderer, types []string) string {
+	painted := make([]string, len(types))
+	for i, name := range types {
+		painted[i] = r.Paint(render.TypeColor(name), name)
+	}
+	return strings.Join(painted, "/")
+}
+
+func teamAnalyze(s *session, in *invocation, name string, members []string) error {
+	if len(members) == 0 {
+		return fmt.Errorf("team %s has no Pokemon to analyze", name)
+	}
+	pokemons, err := teamPokemon(s, members)
+	if err != nil {
+		return err
+	}
+	chart, err := s.api.TypeChart(s.ctx)
+	if err != nil {
+		return err
+	}
+	moveTypes, err := damagingMoveTypes(s, in, pokemons)
+	if err != nil {
+		return err
+	}
+	analysis := analyzeTeam(chart, pokemons, moveTypes)
+	r := s.renderer(in)
+
+	fmt.Fprintf(in.out, "Team %s: %s\n\n", name, strings.Join(members, ", "))
+	table := render.Table{Header: []string{"attack", "weak", "resist"}}
+	for _, typ := range chart.Names() {
+		weak, resist := analysis.weak[typ], analysis.resist[typ]
+		if len(weak)+len(resist) == 0 {
+			continue
+		}
+		table.Rows = append(table.Rows, []string{
+			r.Paint(render.TypeColor(typ), typ), strings.Join(weak, ", "), strings.Join(resist, ", "),
+		})
+	}
+	r.Table(in.out, table)
+
+	fmt.Fprintln(in.out)
+	printTypeList(r, in, "Shared weaknesses", analysis.shared)
+	printTypeList(r, in, "Resisted by no one", analysis.unresisted)
+	printTypeList(r, in, "No super effective moves against", analysis.uncovered)
+
+	fmt.Fprintln(in.out, "\nAverage base stats:")
+	r.Stats(in.out, "  ", analysis.averages, maxBaseStat, render.Default)
+
+	suggestions := suggestTypes(chart, analysis, maxSuggestedTypes)
+	if len(suggestions) == 0 {
+		return nil
+	}
+	onTeam := make(map[string]bool)
+	for _, member := range members {
+		onTeam[member] = true
+	}
+	fmt.Fprintln(in.out, "\nTo fill the gaps, consider:")
+	for _, suggestion := range suggestions {
+		species := suggestSpecies(s, chart[suggestion.typ], onTeam, maxSuggestedSpecies)
+		fmt.Fprintf(in.out, "  %s (%s): %s\n", r.Paint(render.TypeColor(suggestion.typ), suggestion.typ),
+			suggestion.reason, strings.Join(species, ", "))
+		for _, name := range species {
+			in.emit(newRecord(name).set("type", suggestion.typ))
+		}
+	}
+	return nil
+}
+
+func printTypeList(r render.Renderer, in *invocation, label string, types []string) {
+	if len(types) == 0 {
+		types = []string{"none"}
+	}
+	fmt.Fprintf(in.out, "%s: %s\n", label, paintTypes(r, types))
+}
+
+// damagingMoveTypes returns the types of the damaging moves each of
+// pokemons can learn, by name. A Pokemon whose moves can't be looked up
+// is assumed to attack with its own types.
+func damagingMoveTypes(s *session, in *invocation, pokemons []pokeapi.Pokemon) (map[string][]string, error) {
+	var resources []pokeapi.NamedAPIResource
+	for _, pokemon := range pokemons {
+		resources = append(resources, pokemon.MoveResources()...)
+	}
+	fmt.Fprintf(in.out, "Looking up %d moves...\n", len(resources))
+	moves, err := pokeapi.ResolveAll[pokeapi.Move](s.ctx, s.api, resources, 0)
+	if ctxErr := s.ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		fmt.Fprintf(in.out, "Some moves couldn't be looked up: %v\n", err)
+	}
+
+	moveTypes := make(map[string][]string)
+	i := 0
+	for _, pokemon := range pokemons {
+		seen := make(map[string]bool)
+		for range pokemon.Moves {
+			move := moves[i]
+			i++
+			if move.Name == "" || !move.Damaging() || seen[move.Type.Name] {
+				continue
+			}
+			seen[move.Type.Name] = true
+			moveTypes[pokemon.Name] = append(moveTypes[pokemon.Name], move.Type.Name)
+		}
+		if len(moveTypes[pokemon.Name]) == 0 {
+			moveTypes[pokemon.Name] = pokemon.TypeNames()
+		}
+	}
+	return moveTypes, nil
+}
+
+// teamAnalysis is how a team fares against each type.
+type teamAnalysis struct {
+	// weak and resist hold, by attacking type, the members taking more or
+	// less than normal damage from it.
+	weak, resist map[string][]string
+	// shared are the attacking types more members are weak to than
+	// resist, where at least two are weak.
+	shared []string
+	// unresisted are the attacking types no member resists.
+	unresisted []string
+	// uncovered are the defending types none of the members' moves hit
+	// super effectively.
+	uncovered []string
+	averages  []render.Stat
+}
+
+// analyzeTeam works out the strengths and weaknesses of members, whose
+// damaging moves are given as types by member name.
+func analyzeTeam(chart pokeapi.TypeChart, members []pokeapi.Pokemon, moveTypes map[string][]string) teamAnalysis {
+	a := teamAnalysis{
+		weak:   make(map[string][]string),
+		resist: make(map[string][]string),
+	}
+	for _, typ := range chart.Names() {
+		for _, member := range members {
+			switch m := chart.Effectiveness(typ, member.TypeNames()); {
+			case m == 0:
+				a.resist[typ] = append(a.resist[typ], member.Name+" (immune)")
+			case m < 1:
+				a.resist[typ] = append(a.resist[typ], member.Name)
+			case m > 1:
+				a.weak[typ] = append(a.weak[typ], member.Name)
+			}
+		}
+		if weak := len(a.weak[typ]); weak >= 2 && weak > len(a.resist[typ]) {
+			a.shared = append(a.shared, typ)
+		}
+		if len(a.resist[typ]) == 0 {
+			a.unresisted = append(a.unresisted, typ)
+		}
+
+		covered := false
+		for _, member := range members {
+			for _, moveType := range moveTypes[member.Name] {
+				if chart.Effectiveness(moveType, []string{typ}) > 1 {
+					covered = true
+				}
+			}
+		}
+		if !covered {
+			a.uncovered = append(a.uncovered, typ)
+		}
+	}
+
+	totals := make(map[string]int)
+	var order []string
+	for _, member := range members {
+		for _, stat := range member.Stats {
+			if _, ok := totals[stat.Stat.Name]; !ok {
+				order = append(order, stat.Stat.Name)
+			}
+			totals[stat.Stat.Name] += stat.BaseStat
+		}
+	}
+	for _, name := range order {
+		a.averages = append(a.averages, render.Stat{Name: name, Value: totals[name] / len(members)})
+	}
+	return a
+}
+
+// typeSuggestion is a type that would cover some of a team's gaps.
+type typeSuggestion struct {
+	typ    string
+	score  int
+	reason string
+}
+
+// suggestTypes ranks the types by how many of the team's shared
+// weaknesses they resist plus how many of its uncovered types they hit
+// super effectively, returning at most n that help.
+func suggestTypes(chart pokeapi.TypeChart, a teamAnalysis, n int) []typeSuggestion {
+	var suggestions []typeSuggestion
+	for _, typ := range chart.Names() {
+		var resists, hits []string
+		for _, weakness := range a.shared {
+			if chart.Effectiveness(weakness, []string{typ}) < 1 {
+				resists = append(resists, weakness)
+			}
+		}
+		for _, uncovered := range a.uncovered {
+			if chart.Effectiveness(typ, []string{uncovered}) > 1 {
+				hits = append(hits, uncovered)
+			}
+		}
+		if len(resists)+len(hits) == 0 {
+			continue
+		}
+		var reasons []string
+		if len(resists) > 0 {
+			reasons = append(reasons, "resists "+strings.Join(resists, ", "))
+		}
+		if len(hits) > 0 {
+			reasons = append(reasons, "hits "+strings.Join(hits, ", "))
+		}
+		suggestions = append(suggestions, typeSuggestion{
+			typ:    typ,
+			score:  len(resists) + len(hits),
+			reason: strings.Join(reasons, "; "),
+		})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].score > suggestions[j].score
+	})
+	return suggestions[:min(n, len(suggestions))]
+}
+
+// suggestSpecies returns up to n Pokemon of type t that aren't on the
+// team, caught ones first.
+func suggestSpecies(s *session, t pokeapi.Type, onTeam map[string]bool, n int) []string {
+	var species []string
+	for _, name := range sortedKeys(s.game.caught) {
+		pokemon := s.game.caught[name]
+		if len(species) < n && !onTeam[name] && slices.Contains(pokemon.TypeNames(), t.Name) {
+			species = append(species, name)
+		}
+	}
+	for _, p := range t.Pokemon {
+		if len(species) >= n {
+			break
+		}
+		// Alternate forms such as megas have IDs from 10001.
+		if id := p.Pokemon.ID(); id > 0 && id < 10000 && !onTeam[p.Pokemon.Name] &&
+			!slices.Contains(species, p.Pokemon.Name) {
+			species = append(species, p.Pokemon.Name)
+		}
+	}
+	return species
+}
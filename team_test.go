@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donaldnguyen99/pokedexcli/internal/render"
+	"github.com/donaldnguyen99/pokedexcli/pokeapi"
+	"golang.org/x/term"
+)
+
+func testTypeChart(t *testing.T) pokeapi.TypeChart {
+	types := []string{
+		`{"name":"electric","damage_relations":{"double_damage_to":[{"name":"water"},{"name":"flying"}],"half_damage_to":[{"name":"electric"}],"no_damage_to":[{"name":"ground"}]}}`,
+		`{"name":"water","damage_relations":{"double_damage_to":[{"name":"ground"}],"half_damage_to":[{"name":"water"}]},
+			"pokemon":[{"pokemon":{"name":"squirtle","url":"/pokemon/7/"}},{"pokemon":{"name":"gyarados","url":"/pokemon/130/"}},{"pokemon":{"name":"gyarados-mega","url":"/pokemon/10041/"}}]}`,
+		`{"name":"flying","damage_relations":{"half_damage_to":[{"name":"electric"}]}}`,
+		`{"name":"ground","damage_relations":{"double_damage_to":[{"name":"electric"}],"no_damage_to":[{"name":"flying"}]}}`,
+	}
+	chart := make(pokeapi.TypeChart)
+	for _, data := range types {
+		var typ pokeapi.Type
+		if err := json.Unmarshal([]byte(data), &typ); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		chart[typ.Name] = typ
+	}
+	return chart
+}
+
+func testPokemon(t *testing.T, data string) pokeapi.Pokemon {
+	var pokemon pokeapi.Pokemon
+	if err := json.Unmarshal([]byte(data), &pokemon); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return pokemon
+}
+
+func TestAnalyzeTeam(t *testing.T) {
+	chart := testTypeChart(t)
+	pikachu := testPokemon(t, `{"id":25,"name":"pikachu","types":[{"type":{"name":"electric"}}],
+		"stats":[{"base_stat":35,"stat":{"name":"hp"}},{"base_stat":90,"stat":{"name":"speed"}}]}`)
+	raichu := testPokemon(t, `{"id":26,"name":"raichu","types":[{"type":{"name":"electric"}}],
+		"stats":[{"base_stat":60,"stat":{"name":"hp"}},{"base_stat":110,"stat":{"name":"speed"}}]}`)
+	moveTypes := map[string][]string{"pikachu": {"electric"}, "raichu": {"electric", "flying"}}
+
+	a := analyzeTeam(chart, []pokeapi.Pokemon{pikachu, raichu}, moveTypes)
+	if !reflect.DeepEqual(a.shared, []string{"ground"}) {
+		t.Errorf("expected ground to be a shared weakness, got %v", a.shared)
+	}
+	if !reflect.DeepEqual(a.resist["flying"], []string{"pikachu", "raichu"}) {
+		t.Errorf("expected both to resist flying, got %v", a.resist["flying"])
+	}
+	if !reflect.DeepEqual(a.unresisted, []string{"ground", "water"}) {
+		t.Errorf("expected ground and water to be unresisted, got %v", a.unresisted)
+	}
+	if !reflect.DeepEqual(a.uncovered, []string{"electric", "ground"}) {
+		t.Errorf("expected no coverage of electric and ground, got %v", a.uncovered)
+	}
+	expected := []render.Stat{{Name: "hp", Value: 47}, {Name: "speed", Value: 100}}
+	if !reflect.DeepEqual(a.averages, expected) {
+		t.Errorf("expected averages %v, got %v", expected, a.averages)
+	}
+
+	// Flying is immune to ground, ground hits electric and water hits
+	// ground, so they tie.
+	var suggested []string
+	for _, suggestion := range suggestTypes(chart, a, 3) {
+		suggested = append(suggested, suggestion.typ)
+	}
+	if expected := []string{"flying", "ground", "water"}; !reflect.DeepEqual(suggested, expected) {
+		t.Errorf("expected suggestions %v, got %v", expected, suggested)
+	}
+}
+
+func TestTeamMembers(t *testing.T) {
+	var out strings.Builder
+	s := &session{
+		terminal: term.NewTerminal(&screen{strings.NewReader(""), &out}, ""),
+		game:     newGame(),
+	}
+	s.config, _ = loadConfig("")
+	for i, name := range []string{"pikachu", "squirtle", "bulbasaur", "charmander", "eevee", "snorlax", "mew"} {
+		s.game.caught[name] = pokeapi.Pokemon{ID: i + 1, Name: name}
+	}
+
+	if err := teamSet(s, "kanto", nil, []string{"Pikachu", "squirtle", "2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := teamSet(s, "kanto", s.config.Teams["kanto"], []string{"charmander", "eevee", "snorlax", "mew"}); err == nil {
+		t.Errorf("expected an error for a team of seven")
+	}
+	if err := teamRemove(s, "kanto", s.config.Teams["kanto"], []string{"squirtle"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Squirtle was added twice, by name and by ID, and removed once.
+	if expected := []string{"pikachu", "squirtle"}; !reflect.DeepEqual(s.config.Teams["kanto"], expected) {
+		t.Errorf("expected members %v, got %v", expected, s.config.Teams["kanto"])
+	}
+	if err := teamRemove(s, "kanto", s.config.Teams["kanto"], []string{"mew"}); err == nil {
+		t.Errorf("expected an error removing a Pokemon not on the team")
+	}
+	if err := teamRemove(s, "kanto", s.config.Teams["kanto"], []string{"1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"squirtle"}; !reflect.DeepEqual(s.config.Teams["kanto"], expected) {
+		t.Errorf("expected pikachu to be removed by ID, got %v", s.config.Teams["kanto"])
+	}
+
+	onTeam := map[string]bool{"gyarados": true}
+	species := suggestSpecies(s, testTypeChart(t)["water"], onTeam, 3)
+	if !reflect.DeepEqual(species, []string{"squirtle"}) {
+		t.Errorf("expected only squirtle to be suggested, got %v", species)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	opSequence = ";"
+	opAnd      = "&&"
+	opPipe     = "|"
+)
+
+// token is a word or, when op is set, one of the control operators ";",
+// "&&" and "|".
+type token struct {
+	text string
+	op   bool
+}
+
+// lex splits a line of REPL input into tokens the way a shell would: words
+// are separated by whitespace, single quotes preserve everything literally,
+// double quotes allow backslash escapes, and a backslash outside quotes
+// escapes the next character. Unquoted ";", "&&" and "|" are operators even
+// without surrounding spaces. Case is preserved; resource names are
+// normalized later by the lookups that need it.
+func lex(text string) ([]token, error) {
+	var tokens []token
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	endWord := func() {
+		if inWord {
+			tokens = append(tokens, token{text: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\`, runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case unicode.IsSpace(r):
+			endWord()
+		case r == ';':
+			endWord()
+			tokens = append(tokens, token{text: opSequence, op: true})
+		case r == '|':
+			if i+1 < len(runes) && runes[i+1] == '|' {
+				return nil, fmt.Errorf("|| is not supported; use ; or &&")
+			}
+			endWord()
+			tokens = append(tokens, token{text: opPipe, op: true})
+		case r == '&' && i+1 < len(runes) && runes[i+1] == '&':
+			i++
+			endWord()
+			tokens = append(tokens, token{text: opAnd, op: true})
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	endWord()
+	return tokens, nil
+}
+
+// tokenize splits text into words as lex does, keeping operators as plain
+// words.
+func tokenize(text string) ([]string, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.text
+	}
+	return words, nil
+}